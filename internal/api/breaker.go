@@ -0,0 +1,124 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// hostCircuit は1つの接続先（ホスト）の呼び出し状況
+type hostCircuit struct {
+	failures  int       // 続けて失敗した回数
+	openUntil time.Time // この時刻までは呼び出しを止める（ゼロ値なら止めていない）
+	probing   bool      // 止めた後に、試しの呼び出しを1回だけ通している最中かどうか
+}
+
+// circuitBreaker は接続先ごとに失敗を数え、続けて失敗した接続先への呼び出しをすぐに失敗させる
+// 止めてからcooldownが経つと1回だけ試しに呼び出し、成功すれば元に戻す
+type circuitBreaker struct {
+	mu    sync.Mutex
+	hosts map[string]*hostCircuit
+	now   func() time.Time // 現在時刻（テストで差し替える）
+}
+
+// newCircuitBreaker は新しいcircuitBreakerを作成
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{hosts: make(map[string]*hostCircuit), now: time.Now}
+}
+
+// allow はhostを呼び出してよいかを判定し、止めている場合はErrCircuitOpenを返す
+func (cb *circuitBreaker) allow(host string) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	circuit, ok := cb.hosts[host]
+	if !ok || circuit.openUntil.IsZero() {
+		return nil
+	}
+
+	if cb.now().Before(circuit.openUntil) || circuit.probing {
+		return fmt.Errorf("%w: %s", ErrCircuitOpen, host)
+	}
+	circuit.probing = true
+	return nil
+}
+
+// success はhostの呼び出しに成功したことを記録し、止めていた場合は元に戻してtrueを返す
+func (cb *circuitBreaker) success(host string) (resumed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	circuit, ok := cb.hosts[host]
+	if !ok {
+		return false
+	}
+	delete(cb.hosts, host)
+	return !circuit.openUntil.IsZero()
+}
+
+// release は試しの呼び出しが成否の判断前に中断された時、次の呼び出しで改めて試せるようにする
+func (cb *circuitBreaker) release(host string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if circuit, ok := cb.hosts[host]; ok {
+		circuit.probing = false
+	}
+}
+
+// failure はhostの呼び出しに失敗したことを記録し、threshold回続いたらcooldownの間止める
+// 止めた場合は続けて失敗した回数を返す（止めなかった場合は0）
+func (cb *circuitBreaker) failure(host string, threshold int, cooldown time.Duration) (opened int) {
+	if threshold <= 0 {
+		return 0
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	circuit, ok := cb.hosts[host]
+	if !ok {
+		circuit = &hostCircuit{}
+		cb.hosts[host] = circuit
+	}
+
+	circuit.failures++
+	if circuit.probing || circuit.failures >= threshold {
+		circuit.openUntil = cb.now().Add(cooldown)
+		circuit.probing = false
+		return circuit.failures
+	}
+	return 0
+}
+
+// CircuitState は1つの接続先（ホスト）への呼び出しの状態
+type CircuitState struct {
+	Host      string    `json:"host"`                // 接続先のホスト名
+	Open      bool      `json:"open"`                // 呼び出しを止めているかどうか
+	Failures  int       `json:"failures"`            // 続けて失敗した回数
+	OpenUntil time.Time `json:"open_until,omitzero"` // 呼び出しを止めている期限
+}
+
+// states は失敗を記録している接続先の状態をホスト名順に返す
+func (cb *circuitBreaker) states() []CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	states := []CircuitState{}
+	for host, circuit := range cb.hosts {
+		states = append(states, CircuitState{
+			Host:      host,
+			Open:      !circuit.openUntil.IsZero(),
+			Failures:  circuit.failures,
+			OpenUntil: circuit.openUntil,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Host < states[j].Host })
+	return states
+}
+
+// CircuitStates は失敗が記録されている接続先の状態を返す（全て正常なら空）
+func (c *Client) CircuitStates() []CircuitState {
+	return c.breaker.states()
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	const (
+		host      = "example.com"
+		threshold = 3
+		cooldown  = time.Minute
+	)
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	cb := newCircuitBreaker()
+	cb.now = func() time.Time { return now }
+
+	mustAllow := func(step string) {
+		t.Helper()
+		if err := cb.allow(host); err != nil {
+			t.Fatalf("%s: allow = %v, want nil", step, err)
+		}
+	}
+	mustReject := func(step string) {
+		t.Helper()
+		if err := cb.allow(host); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("%s: allow = %v, want ErrCircuitOpen", step, err)
+		}
+	}
+
+	// 閉じている間は、threshold回続けて失敗するまで呼び出せる
+	for i := 1; i < threshold; i++ {
+		mustAllow("closed")
+		if opened := cb.failure(host, threshold, cooldown); opened != 0 {
+			t.Fatalf("opened after %d failures, want %d", i, threshold)
+		}
+	}
+	mustAllow("closed")
+	if opened := cb.failure(host, threshold, cooldown); opened != threshold {
+		t.Fatalf("failure = %d, want %d", opened, threshold)
+	}
+
+	// 開いている間はcooldownが過ぎるまで断る
+	mustReject("open")
+	now = now.Add(cooldown - time.Second)
+	mustReject("open")
+
+	// cooldownが過ぎたら試しの呼び出しを1回だけ通す（半開き）
+	now = now.Add(time.Second)
+	mustAllow("half-open")
+	mustReject("half-open while probing")
+
+	// 試しの呼び出しが失敗したら、また開く
+	if opened := cb.failure(host, threshold, cooldown); opened == 0 {
+		t.Fatal("probe failure did not reopen the circuit")
+	}
+	mustReject("reopened")
+
+	// 試しの呼び出しが中断されたら、次の呼び出しで改めて試す
+	now = now.Add(cooldown)
+	mustAllow("half-open")
+	cb.release(host)
+	mustAllow("half-open after release")
+
+	// 試しの呼び出しが成功したら閉じる
+	if resumed := cb.success(host); !resumed {
+		t.Error("success = false, want true when closing an open circuit")
+	}
+	mustAllow("closed")
+	mustAllow("closed")
+	if states := cb.states(); len(states) != 0 {
+		t.Errorf("states = %v, want none after closing", states)
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	cb := newCircuitBreaker()
+	cb.failure("a", 2, time.Minute)
+	if resumed := cb.success("a"); resumed {
+		t.Error("success = true, want false for a closed circuit")
+	}
+	if opened := cb.failure("a", 2, time.Minute); opened != 0 {
+		t.Error("failures were not reset by success")
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	cb := newCircuitBreaker()
+	for range 10 {
+		if opened := cb.failure("a", 0, time.Minute); opened != 0 {
+			t.Fatal("circuit opened with threshold 0")
+		}
+	}
+	if err := cb.allow("a"); err != nil {
+		t.Errorf("allow = %v, want nil", err)
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"kizuna_bot_go/internal/store"
+)
+
+// cacheEntry はキャッシュした1つのレスポンス
+type cacheEntry struct {
+	Body      []byte    `json:"body"`       // レスポンスボディ（JSON）
+	FetchedAt time.Time `json:"fetched_at"` // APIから取得した時刻
+	ExpiresAt time.Time `json:"expires_at"` // 古いキャッシュとしても使わなくなる時刻（APIごとの有効期間とstale_limitの長い方）
+}
+
+// responseCache は外部APIのレスポンスをURLごとに保持する
+// キーにはURLのハッシュを使い、URLに含まれるAPIキーをデータベースに残さないようにする
+type responseCache struct {
+	mu      sync.Mutex
+	store   store.Store // キャッシュの保存先（nilの場合はメモリ上でのみ保持する）
+	entries map[string]*cacheEntry
+}
+
+// newResponseCache はresponseCacheを作成し、loadがtrueならデータベースに保存されたキャッシュを読み込む
+func newResponseCache(st store.Store, load bool) *responseCache {
+	rc := &responseCache{
+		store:   st,
+		entries: make(map[string]*cacheEntry),
+	}
+
+	if st != nil && load {
+		err := st.ForEach(store.BucketResponseCache, func(key string, decode func(v any) error) error {
+			entry := &cacheEntry{}
+			if err := decode(entry); err != nil {
+				return err
+			}
+			rc.entries[key] = entry
+			return nil
+		})
+		if err != nil {
+			slog.Error("APIのキャッシュの読み込みに失敗しました", "error", err)
+		}
+	}
+	return rc
+}
+
+// get はキーに対応するキャッシュを返す（有効期間を過ぎたものも含む）
+func (rc *responseCache) get(key string) (*cacheEntry, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	return entry, ok
+}
+
+// put はキャッシュを追加し、期限を過ぎたキャッシュを取り除く
+// maxEntriesを超えた場合は、取得した時刻の古いものから取り除く
+// persistがtrueならデータベースにも保存する
+func (rc *responseCache) put(key string, entry *cacheEntry, maxEntries int, persist bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.entries[key] = entry
+	if rc.store != nil && persist {
+		if err := rc.store.Put(store.BucketResponseCache, key, entry); err != nil {
+			slog.Error("APIのキャッシュの保存に失敗しました", "error", err)
+		}
+	}
+
+	// 他のAPIのキャッシュもそれぞれの期限で判断する
+	now := time.Now()
+	for k, e := range rc.entries {
+		if now.After(e.ExpiresAt) {
+			rc.remove(k, persist)
+		}
+	}
+
+	if maxEntries <= 0 || len(rc.entries) <= maxEntries {
+		return
+	}
+	keys := make([]string, 0, len(rc.entries))
+	for k := range rc.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return rc.entries[keys[i]].FetchedAt.Before(rc.entries[keys[j]].FetchedAt)
+	})
+	for _, k := range keys[:len(keys)-maxEntries] {
+		rc.remove(k, persist)
+	}
+}
+
+// remove はキャッシュを取り除き、persistがtrueならデータベースに保存したものも削除する（呼び出し側でロックを取得しておくこと）
+func (rc *responseCache) remove(key string, persist bool) {
+	delete(rc.entries, key)
+	if rc.store != nil && persist {
+		if err := rc.store.Delete(store.BucketResponseCache, key); err != nil {
+			slog.Error("古いAPIのキャッシュの削除に失敗しました", "error", err)
+		}
+	}
+}
+
+// cacheKey はURLからキャッシュのキーを作る
+func cacheKey(targetURL string) string {
+	sum := sha256.Sum256([]byte(targetURL))
+	return hex.EncodeToString(sum[:])
+}
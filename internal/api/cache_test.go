@@ -0,0 +1,137 @@
+package api
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"kizuna_bot_go/internal/config"
+	"kizuna_bot_go/internal/store"
+)
+
+// newEntry はfetchedAgo前に取得し、取得からlifetime後に期限が切れるキャッシュを作成
+func newEntry(fetchedAgo, lifetime time.Duration) *cacheEntry {
+	fetchedAt := time.Now().Add(-fetchedAgo)
+	return &cacheEntry{Body: []byte("{}"), FetchedAt: fetchedAt, ExpiresAt: fetchedAt.Add(lifetime)}
+}
+
+func TestResponseCachePut(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   map[string]*cacheEntry
+		maxEntries int
+		wantKeys   []string
+		wantGone   []string
+	}{
+		{
+			name: "他のAPIのキャッシュは自分の期限で判断する",
+			// 有効期間の短いAPIのキャッシュを追加しても、期限の長いキャッシュは残る
+			existing:   map[string]*cacheEntry{"youtube": newEntry(2*time.Hour, 48*time.Hour)},
+			maxEntries: 10,
+			wantKeys:   []string{"new", "youtube"},
+		},
+		{
+			name:       "期限を過ぎたキャッシュは取り除く",
+			existing:   map[string]*cacheEntry{"old": newEntry(25*time.Hour, 24*time.Hour), "fresh": newEntry(time.Hour, 24*time.Hour)},
+			maxEntries: 10,
+			wantKeys:   []string{"new", "fresh"},
+			wantGone:   []string{"old"},
+		},
+		{
+			name: "最大件数を超えたら取得した時刻の古いものから取り除く",
+			existing: map[string]*cacheEntry{
+				"first":  newEntry(3*time.Hour, 24*time.Hour),
+				"second": newEntry(2*time.Hour, 24*time.Hour),
+				"third":  newEntry(time.Hour, 24*time.Hour),
+			},
+			maxEntries: 2,
+			wantKeys:   []string{"new", "third"},
+			wantGone:   []string{"first", "second"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := store.Open(filepath.Join(t.TempDir(), "cache.db"))
+			if err != nil {
+				t.Fatalf("store.Open: %v", err)
+			}
+			defer st.Close()
+
+			rc := newResponseCache(st, false)
+			for key, entry := range tt.existing {
+				rc.entries[key] = entry
+				if err := st.Put(store.BucketResponseCache, key, entry); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rc.put("new", newEntry(0, time.Hour), tt.maxEntries, true)
+
+			if len(rc.entries) != len(tt.wantKeys) {
+				t.Errorf("cache has %d entries, want %d", len(rc.entries), len(tt.wantKeys))
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := rc.get(key); !ok {
+					t.Errorf("%q was evicted", key)
+				}
+			}
+			for _, key := range tt.wantGone {
+				if _, ok := rc.get(key); ok {
+					t.Errorf("%q was not evicted", key)
+				}
+				// データベースからも削除される
+				if ok, _ := st.Get(store.BucketResponseCache, key, &cacheEntry{}); ok {
+					t.Errorf("%q remains in the store", key)
+				}
+			}
+		})
+	}
+}
+
+// countingStore は書き込みの回数を数えるStore
+type countingStore struct {
+	store.Store
+	writes int
+}
+
+func (s *countingStore) Put(bucket store.Bucket, key string, v any) error {
+	s.writes++
+	return s.Store.Put(bucket, key, v)
+}
+
+func (s *countingStore) Delete(bucket store.Bucket, key string) error {
+	s.writes++
+	return s.Store.Delete(bucket, key)
+}
+
+func TestResponseCacheWithoutPersist(t *testing.T) {
+	st, err := store.Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("store.Open: %v", err)
+	}
+	defer st.Close()
+	counting := &countingStore{Store: st}
+
+	rc := newResponseCache(counting, false)
+	rc.entries["old"] = newEntry(25*time.Hour, 24*time.Hour)
+	rc.entries["older"] = newEntry(3*time.Hour, 24*time.Hour)
+	rc.put("new", newEntry(0, time.Hour), 1, false)
+
+	if len(rc.entries) != 1 {
+		t.Errorf("cache has %d entries, want 1", len(rc.entries))
+	}
+	// 保存しない設定では、追加しても取り除いてもデータベースに書き込まない
+	if counting.writes != 0 {
+		t.Errorf("store was written %d times with persist disabled", counting.writes)
+	}
+}
+
+func TestCachedAPIsMatchConfig(t *testing.T) {
+	// cache.ttlsに書けるキーは、キャッシュを使う外部APIと同じでなければならない
+	cached := []string{apiWeather, apiNews, apiYouTube}
+	if !slices.Equal(cached, config.CachedAPIs) {
+		t.Errorf("cached APIs = %v, config.CachedAPIs = %v", cached, config.CachedAPIs)
+	}
+}
@@ -0,0 +1,410 @@
+package api
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// City は天気予報APIで使える1つの地域（一次細分区域）
+type City struct {
+	ID         int    // 天気予報APIの都市ID（例: 270000）
+	Name       string // 地名（例: 大阪）
+	Kana       string // 地名の読み（ひらがな）
+	Romaji     string // 地名のローマ字表記
+	Prefecture string // 都道府県名
+}
+
+// cities は天気予報APIの地域の一覧（https://weather.tsukumijima.net/primary_area.xml の順）
+var cities = []City{
+	{11000, "稚内", "わっかない", "wakkanai", "北海道"},
+	{12010, "旭川", "あさひかわ", "asahikawa", "北海道"},
+	{12020, "留萌", "るもい", "rumoi", "北海道"},
+	{13010, "網走", "あばしり", "abashiri", "北海道"},
+	{13020, "北見", "きたみ", "kitami", "北海道"},
+	{13030, "紋別", "もんべつ", "monbetsu", "北海道"},
+	{14010, "根室", "ねむろ", "nemuro", "北海道"},
+	{14020, "釧路", "くしろ", "kushiro", "北海道"},
+	{14030, "帯広", "おびひろ", "obihiro", "北海道"},
+	{15010, "室蘭", "むろらん", "muroran", "北海道"},
+	{15020, "浦河", "うらかわ", "urakawa", "北海道"},
+	{16010, "札幌", "さっぽろ", "sapporo", "北海道"},
+	{16020, "岩見沢", "いわみざわ", "iwamizawa", "北海道"},
+	{16030, "倶知安", "くっちゃん", "kutchan", "北海道"},
+	{17010, "函館", "はこだて", "hakodate", "北海道"},
+	{17020, "江差", "えさし", "esashi", "北海道"},
+	{20010, "青森", "あおもり", "aomori", "青森県"},
+	{20020, "むつ", "むつ", "mutsu", "青森県"},
+	{20030, "八戸", "はちのへ", "hachinohe", "青森県"},
+	{30010, "盛岡", "もりおか", "morioka", "岩手県"},
+	{30020, "宮古", "みやこ", "miyako", "岩手県"},
+	{30030, "大船渡", "おおふなと", "ofunato", "岩手県"},
+	{40010, "仙台", "せんだい", "sendai", "宮城県"},
+	{40020, "白石", "しろいし", "shiroishi", "宮城県"},
+	{50010, "秋田", "あきた", "akita", "秋田県"},
+	{50020, "横手", "よこて", "yokote", "秋田県"},
+	{60010, "山形", "やまがた", "yamagata", "山形県"},
+	{60020, "米沢", "よねざわ", "yonezawa", "山形県"},
+	{60030, "酒田", "さかた", "sakata", "山形県"},
+	{60040, "新庄", "しんじょう", "shinjo", "山形県"},
+	{70010, "福島", "ふくしま", "fukushima", "福島県"},
+	{70020, "小名浜", "おなはま", "onahama", "福島県"},
+	{70030, "若松", "わかまつ", "wakamatsu", "福島県"},
+	{80010, "水戸", "みと", "mito", "茨城県"},
+	{80020, "土浦", "つちうら", "tsuchiura", "茨城県"},
+	{90010, "宇都宮", "うつのみや", "utsunomiya", "栃木県"},
+	{90020, "大田原", "おおたわら", "otawara", "栃木県"},
+	{100010, "前橋", "まえばし", "maebashi", "群馬県"},
+	{100020, "みなかみ", "みなかみ", "minakami", "群馬県"},
+	{110010, "さいたま", "さいたま", "saitama", "埼玉県"},
+	{110020, "熊谷", "くまがや", "kumagaya", "埼玉県"},
+	{110030, "秩父", "ちちぶ", "chichibu", "埼玉県"},
+	{120010, "千葉", "ちば", "chiba", "千葉県"},
+	{120020, "銚子", "ちょうし", "choshi", "千葉県"},
+	{120030, "館山", "たてやま", "tateyama", "千葉県"},
+	{130010, "東京", "とうきょう", "tokyo", "東京都"},
+	{130020, "大島", "おおしま", "oshima", "東京都"},
+	{130030, "八丈島", "はちじょうじま", "hachijojima", "東京都"},
+	{130040, "父島", "ちちじま", "chichijima", "東京都"},
+	{140010, "横浜", "よこはま", "yokohama", "神奈川県"},
+	{140020, "小田原", "おだわら", "odawara", "神奈川県"},
+	{150010, "新潟", "にいがた", "niigata", "新潟県"},
+	{150020, "長岡", "ながおか", "nagaoka", "新潟県"},
+	{150030, "高田", "たかだ", "takada", "新潟県"},
+	{150040, "相川", "あいかわ", "aikawa", "新潟県"},
+	{160010, "富山", "とやま", "toyama", "富山県"},
+	{160020, "伏木", "ふしき", "fushiki", "富山県"},
+	{170010, "金沢", "かなざわ", "kanazawa", "石川県"},
+	{170020, "輪島", "わじま", "wajima", "石川県"},
+	{180010, "福井", "ふくい", "fukui", "福井県"},
+	{180020, "敦賀", "つるが", "tsuruga", "福井県"},
+	{190010, "甲府", "こうふ", "kofu", "山梨県"},
+	{190020, "河口湖", "かわぐちこ", "kawaguchiko", "山梨県"},
+	{200010, "長野", "ながの", "nagano", "長野県"},
+	{200020, "松本", "まつもと", "matsumoto", "長野県"},
+	{200030, "飯田", "いいだ", "iida", "長野県"},
+	{210010, "岐阜", "ぎふ", "gifu", "岐阜県"},
+	{210020, "高山", "たかやま", "takayama", "岐阜県"},
+	{220010, "静岡", "しずおか", "shizuoka", "静岡県"},
+	{220020, "網代", "あじろ", "ajiro", "静岡県"},
+	{220030, "三島", "みしま", "mishima", "静岡県"},
+	{220040, "浜松", "はままつ", "hamamatsu", "静岡県"},
+	{230010, "名古屋", "なごや", "nagoya", "愛知県"},
+	{230020, "豊橋", "とよはし", "toyohashi", "愛知県"},
+	{240010, "津", "つ", "tsu", "三重県"},
+	{240020, "尾鷲", "おわせ", "owase", "三重県"},
+	{250010, "大津", "おおつ", "otsu", "滋賀県"},
+	{250020, "彦根", "ひこね", "hikone", "滋賀県"},
+	{260010, "京都", "きょうと", "kyoto", "京都府"},
+	{260020, "舞鶴", "まいづる", "maizuru", "京都府"},
+	{270000, "大阪", "おおさか", "osaka", "大阪府"},
+	{280010, "神戸", "こうべ", "kobe", "兵庫県"},
+	{280020, "豊岡", "とよおか", "toyooka", "兵庫県"},
+	{290010, "奈良", "なら", "nara", "奈良県"},
+	{290020, "風屋", "かぜや", "kazeya", "奈良県"},
+	{300010, "和歌山", "わかやま", "wakayama", "和歌山県"},
+	{300020, "潮岬", "しおのみさき", "shionomisaki", "和歌山県"},
+	{310010, "鳥取", "とっとり", "tottori", "鳥取県"},
+	{310020, "米子", "よなご", "yonago", "鳥取県"},
+	{320010, "松江", "まつえ", "matsue", "島根県"},
+	{320020, "浜田", "はまだ", "hamada", "島根県"},
+	{320030, "西郷", "さいごう", "saigo", "島根県"},
+	{330010, "岡山", "おかやま", "okayama", "岡山県"},
+	{330020, "津山", "つやま", "tsuyama", "岡山県"},
+	{340010, "広島", "ひろしま", "hiroshima", "広島県"},
+	{340020, "庄原", "しょうばら", "shobara", "広島県"},
+	{350010, "下関", "しものせき", "shimonoseki", "山口県"},
+	{350020, "山口", "やまぐち", "yamaguchi", "山口県"},
+	{350030, "柳井", "やない", "yanai", "山口県"},
+	{350040, "萩", "はぎ", "hagi", "山口県"},
+	{360010, "徳島", "とくしま", "tokushima", "徳島県"},
+	{360020, "日和佐", "ひわさ", "hiwasa", "徳島県"},
+	{370000, "高松", "たかまつ", "takamatsu", "香川県"},
+	{380010, "松山", "まつやま", "matsuyama", "愛媛県"},
+	{380020, "新居浜", "にいはま", "niihama", "愛媛県"},
+	{380030, "宇和島", "うわじま", "uwajima", "愛媛県"},
+	{390010, "高知", "こうち", "kochi", "高知県"},
+	{390020, "室戸岬", "むろとみさき", "murotomisaki", "高知県"},
+	{390030, "清水", "しみず", "shimizu", "高知県"},
+	{400010, "福岡", "ふくおか", "fukuoka", "福岡県"},
+	{400020, "八幡", "やはた", "yahata", "福岡県"},
+	{400030, "飯塚", "いいづか", "iizuka", "福岡県"},
+	{400040, "久留米", "くるめ", "kurume", "福岡県"},
+	{410010, "佐賀", "さが", "saga", "佐賀県"},
+	{410020, "伊万里", "いまり", "imari", "佐賀県"},
+	{420010, "長崎", "ながさき", "nagasaki", "長崎県"},
+	{420020, "佐世保", "させぼ", "sasebo", "長崎県"},
+	{420030, "厳原", "いづはら", "izuhara", "長崎県"},
+	{420040, "福江", "ふくえ", "fukue", "長崎県"},
+	{430010, "熊本", "くまもと", "kumamoto", "熊本県"},
+	{430020, "阿蘇乙姫", "あそおとひめ", "asootohime", "熊本県"},
+	{430030, "牛深", "うしぶか", "ushibuka", "熊本県"},
+	{430040, "人吉", "ひとよし", "hitoyoshi", "熊本県"},
+	{440010, "大分", "おおいた", "oita", "大分県"},
+	{440020, "中津", "なかつ", "nakatsu", "大分県"},
+	{440030, "日田", "ひた", "hita", "大分県"},
+	{440040, "佐伯", "さいき", "saiki", "大分県"},
+	{450010, "宮崎", "みやざき", "miyazaki", "宮崎県"},
+	{450020, "延岡", "のべおか", "nobeoka", "宮崎県"},
+	{450030, "都城", "みやこのじょう", "miyakonojo", "宮崎県"},
+	{450040, "高千穂", "たかちほ", "takachiho", "宮崎県"},
+	{460010, "鹿児島", "かごしま", "kagoshima", "鹿児島県"},
+	{460020, "鹿屋", "かのや", "kanoya", "鹿児島県"},
+	{460030, "種子島", "たねがしま", "tanegashima", "鹿児島県"},
+	{460040, "名瀬", "なぜ", "naze", "鹿児島県"},
+	{471010, "那覇", "なは", "naha", "沖縄県"},
+	{471020, "名護", "なご", "nago", "沖縄県"},
+	{471030, "久米島", "くめじま", "kumejima", "沖縄県"},
+	{472000, "南大東", "みなみだいとう", "minamidaito", "沖縄県"},
+	{473000, "宮古島", "みやこじま", "miyakojima", "沖縄県"},
+	{474010, "石垣島", "いしがきじま", "ishigakijima", "沖縄県"},
+	{474020, "与那国島", "よなぐにじま", "yonagunijima", "沖縄県"},
+}
+
+// prefectures は都道府県名で探された時に使う地域（県庁所在地のある地域）
+var prefectures = []struct {
+	name, kana, romaji string
+	cityID             int
+}{
+	{"北海道", "ほっかいどう", "hokkaido", 16010},
+	{"青森県", "あおもり", "aomori", 20010},
+	{"岩手県", "いわて", "iwate", 30010},
+	{"宮城県", "みやぎ", "miyagi", 40010},
+	{"秋田県", "あきた", "akita", 50010},
+	{"山形県", "やまがた", "yamagata", 60010},
+	{"福島県", "ふくしま", "fukushima", 70010},
+	{"茨城県", "いばらき", "ibaraki", 80010},
+	{"栃木県", "とちぎ", "tochigi", 90010},
+	{"群馬県", "ぐんま", "gunma", 100010},
+	{"埼玉県", "さいたま", "saitama", 110010},
+	{"千葉県", "ちば", "chiba", 120010},
+	{"東京都", "とうきょう", "tokyo", 130010},
+	{"神奈川県", "かながわ", "kanagawa", 140010},
+	{"新潟県", "にいがた", "niigata", 150010},
+	{"富山県", "とやま", "toyama", 160010},
+	{"石川県", "いしかわ", "ishikawa", 170010},
+	{"福井県", "ふくい", "fukui", 180010},
+	{"山梨県", "やまなし", "yamanashi", 190010},
+	{"長野県", "ながの", "nagano", 200010},
+	{"岐阜県", "ぎふ", "gifu", 210010},
+	{"静岡県", "しずおか", "shizuoka", 220010},
+	{"愛知県", "あいち", "aichi", 230010},
+	{"三重県", "みえ", "mie", 240010},
+	{"滋賀県", "しが", "shiga", 250010},
+	{"京都府", "きょうと", "kyoto", 260010},
+	{"大阪府", "おおさか", "osaka", 270000},
+	{"兵庫県", "ひょうご", "hyogo", 280010},
+	{"奈良県", "なら", "nara", 290010},
+	{"和歌山県", "わかやま", "wakayama", 300010},
+	{"鳥取県", "とっとり", "tottori", 310010},
+	{"島根県", "しまね", "shimane", 320010},
+	{"岡山県", "おかやま", "okayama", 330010},
+	{"広島県", "ひろしま", "hiroshima", 340010},
+	{"山口県", "やまぐち", "yamaguchi", 350020},
+	{"徳島県", "とくしま", "tokushima", 360010},
+	{"香川県", "かがわ", "kagawa", 370000},
+	{"愛媛県", "えひめ", "ehime", 380010},
+	{"高知県", "こうち", "kochi", 390010},
+	{"福岡県", "ふくおか", "fukuoka", 400010},
+	{"佐賀県", "さが", "saga", 410010},
+	{"長崎県", "ながさき", "nagasaki", 420010},
+	{"熊本県", "くまもと", "kumamoto", 430010},
+	{"大分県", "おおいた", "oita", 440010},
+	{"宮崎県", "みやざき", "miyazaki", 450010},
+	{"鹿児島県", "かごしま", "kagoshima", 460010},
+	{"沖縄県", "おきなわ", "okinawa", 471010},
+}
+
+// cityIndex は正規化した地名・読み・ローマ字から都市IDを引く索引
+// 地域名と都道府県名が同じ場合は地域名を優先する
+var cityIndex = buildCityIndex()
+
+// buildCityIndex はcitiesとprefecturesからcityIndexを作成
+func buildCityIndex() map[string]int {
+	index := make(map[string]int)
+	add := func(key string, cityID int) {
+		if _, ok := index[key]; !ok && key != "" {
+			index[key] = cityID
+		}
+	}
+	for _, city := range cities {
+		add(normalizePlace(city.Name), city.ID)
+		add(normalizePlace(city.Kana), city.ID)
+		add(normalizePlace(city.Romaji), city.ID)
+	}
+	for _, pref := range prefectures {
+		add(normalizePlace(pref.name), pref.cityID)
+		add(normalizePlace(strings.TrimRight(pref.name, "都府県")), pref.cityID)
+		add(normalizePlace(pref.kana), pref.cityID)
+		add(normalizePlace(pref.romaji), pref.cityID)
+	}
+	return index
+}
+
+// CityByID は都市IDから地域を返す
+func CityByID(id int) (City, bool) {
+	for _, city := range cities {
+		if city.ID == id {
+			return city, true
+		}
+	}
+	return City{}, false
+}
+
+// LookupCity は地名（漢字・ひらがな・カタカナ・ローマ字）から地域を探す
+// 都道府県名の場合は県庁所在地のある地域を返す
+// 見つからなかった場合はokがfalseになり、似た名前の地域を近い順に最大3件suggestionsに返す
+func LookupCity(name string) (city City, suggestions []City, ok bool) {
+	key := normalizePlace(name)
+	if key == "" {
+		return City{}, nil, false
+	}
+
+	// 「大阪市」「大阪府」のような市や都道府県の付いた地名は、付けずに探し直す
+	for _, candidate := range []string{key, trimPlaceSuffix(key)} {
+		if id, found := cityIndex[candidate]; found {
+			city, _ := CityByID(id)
+			return city, nil, true
+		}
+	}
+
+	return City{}, suggestCities(key, 3), false
+}
+
+// CityInAddress は「東京都新宿区」や「大阪市北区」のような住所に含まれる地名から地域を探す
+// 住所が都道府県名から始まる場合は、残りの部分に地域名がなければその都道府県の地域を返す
+// 地域名が複数含まれる場合は住所の後ろの方（より細かい方）にあるものを使う
+// 「津」のような1文字の地名は、他の地名の一部と区別できないため「津市」と書かれている場合のみ使う
+func CityInAddress(address string) (City, bool) {
+	if city, _, ok := LookupCity(address); ok {
+		return city, true
+	}
+
+	// 「東京都」の中の「京都」のように、都道府県名の一部を地域名と取り違えないよう先に取り除く
+	prefCityID := 0
+	for _, pref := range prefectures {
+		if rest, found := strings.CutPrefix(address, pref.name); found {
+			address, prefCityID = rest, pref.cityID
+			break
+		}
+	}
+
+	best, bestEnd := City{}, -1
+	for _, city := range cities {
+		name := city.Name
+		if utf8.RuneCountInString(name) < 2 {
+			name += "市"
+		}
+		i := strings.LastIndex(address, name)
+		if i < 0 {
+			continue
+		}
+		end := i + len(name)
+		if end > bestEnd || (end == bestEnd && len(city.Name) > len(best.Name)) {
+			best, bestEnd = city, end
+		}
+	}
+	if bestEnd >= 0 {
+		return best, true
+	}
+
+	if prefCityID > 0 {
+		return CityByID(prefCityID)
+	}
+	return City{}, false
+}
+
+// suggestCities は正規化した地名keyに似た地域を、近い順に最大max件返す
+// 前方一致する地域と、編集距離が地名の長さの1/3以内（最低1）の地域を候補にする
+func suggestCities(key string, max int) []City {
+	threshold := utf8.RuneCountInString(key) / 3
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	distances := make(map[int]int) // 都市ID → 最も近いキーとの距離
+	for candidate, id := range cityIndex {
+		distance := levenshtein(key, candidate)
+		if strings.HasPrefix(candidate, key) {
+			distance = 0
+		}
+		if distance > threshold {
+			continue
+		}
+		if d, ok := distances[id]; !ok || distance < d {
+			distances[id] = distance
+		}
+	}
+
+	var suggestions []City
+	for _, city := range cities {
+		if _, ok := distances[city.ID]; ok {
+			suggestions = append(suggestions, city)
+		}
+	}
+	// 距離が同じ場合は一覧の順（北から南）に並べる
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return distances[suggestions[i].ID] < distances[suggestions[j].ID]
+	})
+	if len(suggestions) > max {
+		suggestions = suggestions[:max]
+	}
+	return suggestions
+}
+
+// romajiReplacer はローマ字の長音の書き方の違い（Ōsaka, Oosaka, Toukyou など）をそろえる
+var romajiReplacer = strings.NewReplacer("ō", "o", "ô", "o", "ū", "u", "û", "u", "oo", "o", "ou", "o", "uu", "u")
+
+// normalizePlace は地名を比較しやすい形にする
+// 空白や記号を取り除き、カタカナはひらがなに、ローマ字は小文字にして長音の書き方をそろえる
+func normalizePlace(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, suffix := range []string{" city", " prefecture", "-shi", "-ken", "-fu"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'ァ' && r <= 'ヶ':
+			b.WriteRune(r - 'ァ' + 'ぁ')
+		case r == ' ' || r == '　' || r == '-' || r == '\'' || r == '・':
+			// 区切りの記号は無視する
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return romajiReplacer.Replace(b.String())
+}
+
+// trimPlaceSuffix は「市」「県」などの末尾の1文字を取り除く（北海道の「道」は地名の一部なので残す）
+func trimPlaceSuffix(key string) string {
+	for _, suffix := range []string{"市", "町", "村", "都", "府", "県"} {
+		if trimmed := strings.TrimSuffix(key, suffix); trimmed != key && trimmed != "" {
+			return trimmed
+		}
+	}
+	return key
+}
+
+// levenshtein は2つの文字列の編集距離（文字単位）を返す
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
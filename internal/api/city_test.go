@@ -0,0 +1,97 @@
+package api
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLookupCity(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		wantID          int
+		wantSuggestions []string // 見つからない場合に候補として返る地名（順番どおり）
+	}{
+		{name: "漢字", input: "大阪", wantID: 270000},
+		{name: "ひらがな", input: "おおさか", wantID: 270000},
+		{name: "カタカナ", input: "オオサカ", wantID: 270000},
+		{name: "ローマ字", input: "osaka", wantID: 270000},
+		{name: "ローマ字の大文字", input: "Osaka", wantID: 270000},
+		{name: "ローマ字の長音（oo）", input: "oosaka", wantID: 270000},
+		{name: "ローマ字の長音（ō）", input: "ōsaka", wantID: 270000},
+		{name: "ローマ字の長音（ou）", input: "tokyou", wantID: 130010},
+		{name: "ローマ字の長音（ou・2つ）", input: "toukyou", wantID: 130010},
+		{name: "ローマ字の長音（マクロン・2つ）", input: "tōkyō", wantID: 130010},
+		{name: "「の」を含む地名", input: "しものせき", wantID: 350010},
+		{name: "「の」を含む地名のローマ字", input: "shimonoseki", wantID: 350010},
+		{name: "市を付けた地名", input: "札幌市", wantID: 16010},
+		{name: "府を付けた地名", input: "大阪府", wantID: 270000},
+		{name: "都道府県名は県庁所在地", input: "北海道", wantID: 16010},
+		{name: "県名", input: "沖縄県", wantID: 471010},
+		{name: "1文字の地名", input: "津", wantID: 240010},
+		{name: "1文字の地名に市", input: "津市", wantID: 240010},
+		{name: "打ち間違い", input: "さっぽっろ", wantSuggestions: []string{"札幌"}},
+		{name: "ローマ字の途中まで", input: "なごy", wantSuggestions: []string{"名古屋", "名護"}},
+		{name: "途中まで", input: "おおさ", wantSuggestions: []string{"大阪", "大津"}},
+		{name: "似た地名もない", input: "ほげほげ"},
+		{name: "空", input: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			city, suggestions, ok := LookupCity(tt.input)
+			if tt.wantID != 0 {
+				if !ok || city.ID != tt.wantID {
+					t.Errorf("LookupCity(%q) = (%d, %v), want %d", tt.input, city.ID, ok, tt.wantID)
+				}
+				return
+			}
+
+			if ok {
+				t.Fatalf("LookupCity(%q) = %s, want not found", tt.input, city.Name)
+			}
+			var names []string
+			for _, suggestion := range suggestions {
+				names = append(names, suggestion.Name)
+			}
+			if !slices.Equal(names, tt.wantSuggestions) {
+				t.Errorf("LookupCity(%q) suggestions = %v, want %v", tt.input, names, tt.wantSuggestions)
+			}
+		})
+	}
+}
+
+func TestCityInAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		wantID  int // 0なら見つからない
+	}{
+		// 「東京都」の中の「京都」を拾わない
+		{address: "東京都新宿区", wantID: 130010},
+		{address: "京都府京都市", wantID: 260010},
+		{address: "大阪府大阪市北区梅田", wantID: 270000},
+		{address: "神奈川県横浜市", wantID: 140010},
+		{address: "三重県津市", wantID: 240010},
+		// 1文字の地名は「市」が付いている時だけ
+		{address: "津田沼"},
+		{address: "どこか"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			city, ok := CityInAddress(tt.address)
+			if ok != (tt.wantID != 0) || city.ID != tt.wantID {
+				t.Errorf("CityInAddress(%q) = (%d, %v), want %d", tt.address, city.ID, ok, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestCityByID(t *testing.T) {
+	if city, ok := CityByID(130010); !ok || city.Name != "東京" {
+		t.Errorf("CityByID(130010) = (%+v, %v), want 東京", city, ok)
+	}
+	if _, ok := CityByID(1); ok {
+		t.Error("CityByID(1) found a city")
+	}
+}
@@ -1,57 +1,285 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"kizuna_bot_go/internal/config"
+	"kizuna_bot_go/internal/logging"
+	"kizuna_bot_go/internal/metrics"
+	"kizuna_bot_go/internal/store"
+)
+
+// 外部APIの名前（キャッシュの設定のキーや、メトリクスのラベルに使う）
+const (
+	apiWeather      = "weather"       // 天気予報API
+	apiNews         = "news"          // RSS2JSON API（ニュース）
+	apiHotPepper    = "hotpepper"     // ホットペッパーAPI（グルメ検索）
+	apiCustomSearch = "custom_search" // Google カスタム検索API（画像検索）
+	apiYouTube      = "youtube"       // YouTube Data API（動画検索）
+	apiTranslate    = "translate"     // Google翻訳API
 )
 
 // Client は全ての外部API呼び出しを処理するHTTPクライアント
 type Client struct {
-	httpClient *http.Client   // HTTP通信用のクライアント
-	config     *config.Config // 設定情報（APIキーやエンドポイントなど）
+	httpClient *http.Client                  // HTTP通信用のクライアント
+	config     atomic.Pointer[config.Config] // 設定情報（APIキーやエンドポイントなど）、再読み込みで差し替わる
+	quota      *quotaAccountant              // 1日の利用上限のあるAPIの利用量
+	cache      *responseCache                // 外部APIのレスポンスのキャッシュ
+	breaker    *circuitBreaker               // 失敗が続く接続先への呼び出しを止める
 }
 
 // NewClient は新しいAPIクライアントを作成
-func NewClient(cfg *config.Config) *Client {
-	return &Client{
+// stにはAPIの利用量（と設定で有効にした場合はレスポンスのキャッシュ）を保存し、再起動しても引き継ぐ
+// stがnilの場合はメモリ上でのみ保持する
+func NewClient(cfg *config.Config, st store.Store) *Client {
+	c := &Client{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second, // タイムアウトを30秒に設定
 		},
-		config: cfg,
+		quota:   newQuotaAccountant(st),
+		cache:   newResponseCache(st, cfg.Cache.Persist),
+		breaker: newCircuitBreaker(),
 	}
+	c.config.Store(cfg)
+	return c
+}
+
+// SetConfig は使用する設定を差し替える
+// 実行中のリクエストは差し替え前の設定のまま完了する
+func (c *Client) SetConfig(cfg *config.Config) {
+	c.config.Store(cfg)
+}
+
+// cfg は現在の設定を返す
+// 1回のAPI呼び出しの中では最初に取得した設定を使い続けること
+func (c *Client) cfg() *config.Config {
+	return c.config.Load()
 }
 
 // makeGetRequest は指定されたURLにGETリクエストを送信し、結果をJSONとして解析
-func (c *Client) makeGetRequest(targetURL string, result interface{}) error {
+// nameには呼び出す外部APIの名前（apiWeather など）を渡す
+// beforeAttemptは再試行を含めて実際にリクエストを送る直前に毎回呼ばれ、エラーを返すと呼び出しをやめる（nilでもよい）
+func (c *Client) makeGetRequest(ctx context.Context, name, targetURL string, result interface{}, beforeAttempt func() error) error {
+	body, err := c.fetch(ctx, name, targetURL, beforeAttempt)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(body, result)
+}
+
+// makeCachedGetRequest はmakeGetRequestと同様にGETリクエストを送信するが、
+// 設定の cache.ttls でnameに指定された期間内は、同じURLへの前回のレスポンスを使い回す
+// beforeAttemptはキャッシュを使えず実際にリクエストを送る直前に（再試行のたびに）呼ばれ、エラーを返すと呼び出しをやめる（nilでもよい）
+// APIの呼び出しに失敗した場合は、cache.stale_limit 以内に取得した古いキャッシュがあればそれを使う
+func (c *Client) makeCachedGetRequest(ctx context.Context, name, targetURL string, result interface{}, beforeAttempt func() error) error {
+	cfg := c.cfg()
+	ttl := cfg.Cache.TTLs[name]
+	if ttl <= 0 {
+		return c.makeGetRequest(ctx, name, targetURL, result, beforeAttempt)
+	}
+
+	key := cacheKey(targetURL)
+	cached, ok := c.cache.get(key)
+	if ok && time.Since(cached.FetchedAt) < ttl {
+		return decodeResponse(cached.Body, result)
+	}
+
+	body, err := c.fetch(ctx, name, targetURL, beforeAttempt)
+	if err == nil {
+		// 壊れたレスポンスをキャッシュしないよう、解析できたものだけを保存する
+		if err = decodeResponse(body, result); err == nil {
+			now := time.Now()
+			entry := &cacheEntry{Body: body, FetchedAt: now, ExpiresAt: now.Add(max(ttl, cfg.Cache.StaleLimit))}
+			c.cache.put(key, entry, cfg.Cache.MaxEntries, cfg.Cache.Persist)
+			return nil
+		}
+	}
+
+	// 中断された場合は、呼び出し元がもう結果を待っていないので古いキャッシュも返さない
+	if ok && ctx.Err() == nil && time.Since(cached.FetchedAt) < cfg.Cache.StaleLimit {
+		logging.FromContext(ctx).Warn("APIの呼び出しに失敗したため、古いキャッシュを使います",
+			"age", time.Since(cached.FetchedAt).Round(time.Second), "error", err)
+		return decodeResponse(cached.Body, result)
+	}
+	return err
+}
+
+// fetch は指定されたURLにGETリクエストを送信し、レスポンスボディを返す
+// 接続エラー・タイムアウト・5xx・429の場合は設定に従って再試行し、
+// 失敗が続いている接続先にはリクエストを送らずにErrCircuitOpenを返す
+// ctxがキャンセルされた場合は再試行せず、接続先の失敗としても数えない
+// beforeAttemptはリクエストを送る直前に毎回呼ばれ（利用量を数えるため。nilでもよい）、エラーを返すとそのエラーで呼び出しをやめる
+func (c *Client) fetch(ctx context.Context, name, targetURL string, beforeAttempt func() error) ([]byte, error) {
+	cfg := c.cfg()
+
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request URL: %w", err)
+	}
+	if err := c.breaker.allow(u.Host); err != nil {
+		metrics.UpstreamErrors.WithLabelValues(name, "circuit_open").Inc()
+		return nil, err
+	}
+
+	logger := logging.FromContext(ctx).With("host", u.Host)
+	for attempt := 1; ; attempt++ {
+		if beforeAttempt != nil {
+			if err := beforeAttempt(); err != nil {
+				c.breaker.release(u.Host)
+				return nil, err
+			}
+		}
+
+		start := time.Now()
+		body, err := c.fetchOnce(ctx, targetURL, cfg.RequestTimeout)
+		duration := time.Since(start)
+		logger.Debug("外部APIを呼び出しました", "api", name, "attempt", attempt, "duration", duration, "error", err)
+		metrics.UpstreamDuration.WithLabelValues(name).Observe(duration.Seconds())
+		if err != nil {
+			metrics.UpstreamErrors.WithLabelValues(name, errorReason(err)).Inc()
+		}
+		if err == nil {
+			if c.breaker.success(u.Host) {
+				logger.Info("失敗が続いていた接続先への呼び出しを再開します")
+			}
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			c.breaker.release(u.Host)
+			return nil, err
+		}
+
+		var statusErr *statusError
+		isStatusErr := errors.As(err, &statusErr)
+
+		// 4xxはリクエストの内容の問題なので、再試行せず接続先の失敗としても数えない
+		if isStatusErr && statusErr.code < http.StatusInternalServerError && statusErr.code != http.StatusTooManyRequests {
+			c.breaker.success(u.Host)
+			return nil, err
+		}
+
+		delay := backoffDelay(cfg.Retry, attempt)
+		if isStatusErr && statusErr.retryAfter > 0 {
+			delay = statusErr.retryAfter
+		}
+		if attempt >= cfg.Retry.MaxAttempts || delay > cfg.Retry.MaxDelay {
+			if isStatusErr && statusErr.code == http.StatusTooManyRequests {
+				// 429は接続先が応答できているので、止める対象にはしない
+				c.breaker.success(u.Host)
+			} else if failures := c.breaker.failure(u.Host, cfg.CircuitBreaker.Threshold, cfg.CircuitBreaker.Cooldown); failures > 0 {
+				logger.Warn("接続先への呼び出しが続けて失敗したため、しばらく止めます",
+					"failures", failures, "cooldown", cfg.CircuitBreaker.Cooldown)
+			}
+			return nil, err
+		}
+
+		logger.Warn("外部APIの呼び出しに失敗したため、再試行します",
+			"attempt", attempt, "max_attempts", cfg.Retry.MaxAttempts, "delay", delay.Round(time.Millisecond), "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			c.breaker.release(u.Host)
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// fetchOnce はタイムアウト付きでGETリクエストを1回だけ送信し、レスポンスボディを返す
+func (c *Client) fetchOnce(ctx context.Context, targetURL string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
 	// HTTPリクエストを実行
-	resp, err := c.httpClient.Get(targetURL)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make request: %w: %w", ErrUpstreamUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	// HTTPステータスコードをチェック
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+		return nil, &statusError{code: resp.StatusCode, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
 	}
 
 	// レスポンスボディを読み取り
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w: %w", ErrUpstreamUnavailable, err)
 	}
+	return body, nil
+}
 
-	// JSONを構造体にパース
-	if err := json.Unmarshal(body, result); err != nil {
-		return fmt.Errorf("failed to unmarshal response: %w", err)
+// errorReason はメトリクスのラベルに使う、失敗の理由の分類を返す
+func errorReason(err error) string {
+	var statusErr *statusError
+	if !errors.As(err, &statusErr) {
+		return "network"
+	}
+	if statusErr.code >= http.StatusInternalServerError {
+		return "http_5xx"
 	}
+	return "http_4xx"
+}
+
+// statusError は外部APIが200以外のステータスコードを返した時のエラー
+type statusError struct {
+	code       int           // HTTPステータスコード
+	retryAfter time.Duration // Retry-Afterヘッダーで指定された待ち時間（なければ0）
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("API request failed with status: %d", e.code)
+}
+
+// backoffDelay はattempt回目の失敗の後、再試行するまでの待ち時間を返す
+// 待ち時間は倍々に延ばし、同時に失敗したリクエストが一斉に再試行しないよう後半の半分をランダムにする
+func backoffDelay(retry config.Retry, attempt int) time.Duration {
+	delay := retry.BaseDelay << (attempt - 1)
+	if delay > retry.MaxDelay || delay <= 0 {
+		delay = retry.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// parseRetryAfter はRetry-Afterヘッダーの値（秒数または日時）を、nowからの待ち時間にする
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
 
+// decodeResponse はレスポンスボディのJSONを構造体にパース
+func decodeResponse(body []byte, result interface{}) error {
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w: %w", ErrUpstreamUnavailable, err)
+	}
 	return nil
 }
 
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kizuna_bot_go/internal/config"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "なし", value: "", want: 0},
+		{name: "秒数", value: "120", want: 2 * time.Minute},
+		{name: "0秒", value: "0", want: 0},
+		{name: "負の秒数", value: "-5", want: 0},
+		{name: "日時", value: "Sun, 18 Oct 2026 12:00:30 GMT", want: 30 * time.Second},
+		{name: "過去の日時", value: "Sun, 18 Oct 2026 11:59:00 GMT", want: 0},
+		{name: "RFC 850形式の日時", value: "Sunday, 18-Oct-26 12:01:00 GMT", want: time.Minute},
+		{name: "解析できない値", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	retry := config.Retry{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		name    string
+		retry   config.Retry
+		attempt int
+		max     time.Duration // 待ち時間はmaxの半分からmaxまでの範囲になる
+	}{
+		{name: "1回目", retry: retry, attempt: 1, max: 100 * time.Millisecond},
+		{name: "2回目は倍", retry: retry, attempt: 2, max: 200 * time.Millisecond},
+		{name: "4回目", retry: retry, attempt: 4, max: 800 * time.Millisecond},
+		{name: "max_delayで頭打ち", retry: retry, attempt: 5, max: time.Second},
+		{name: "桁あふれしてもmax_delay", retry: retry, attempt: 80, max: time.Second},
+		{name: "max_delayが0なら待たない", retry: config.Retry{BaseDelay: time.Second}, attempt: 1, max: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				got := backoffDelay(tt.retry, tt.attempt)
+				if got < tt.max/2 || got > tt.max {
+					t.Fatalf("backoffDelay(attempt=%d) = %v, want between %v and %v", tt.attempt, got, tt.max/2, tt.max)
+				}
+			}
+		})
+	}
+}
+
+// newTestClient はテスト用の設定でClientを作成する（再試行の待ち時間は短くする）
+func newTestClient(t *testing.T, modify func(cfg *config.Config)) *Client {
+	t.Helper()
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	cfg.Retry = config.Retry{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	cfg.CircuitBreaker = config.CircuitBreaker{Threshold: 2, Cooldown: time.Minute}
+	if modify != nil {
+		modify(cfg)
+	}
+	return NewClient(cfg, nil)
+}
+
+func TestFetchRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int // 呼び出しごとに返すステータスコード（尽きたら200）
+		retryAfter   string
+		wantErr      error
+		wantStatus   int // 再試行しないステータスコードがそのまま返ることを期待する場合
+		wantRequests int32
+	}{
+		{name: "成功", statuses: nil, wantRequests: 1},
+		{name: "5xxの後に成功", statuses: []int{500, 503}, wantRequests: 3},
+		{name: "5xxが続けば諦める", statuses: []int{500, 500, 500, 500}, wantErr: ErrUpstreamUnavailable, wantRequests: 3},
+		{name: "4xxは再試行しない", statuses: []int{404}, wantStatus: 404, wantRequests: 1},
+		{name: "429はRetry-Afterが短ければ待って再試行", statuses: []int{429}, retryAfter: "0", wantRequests: 2},
+		{name: "Retry-Afterがmax_delayより長ければ諦める", statuses: []int{429}, retryAfter: "60", wantErr: ErrUpstreamUnavailable, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				if n <= len(tt.statuses) {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.statuses[n-1])
+					return
+				}
+				w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+
+			c := newTestClient(t, nil)
+			body, err := c.fetch(context.Background(), apiWeather, server.URL, nil)
+			switch {
+			case tt.wantStatus != 0:
+				var statusErr *statusError
+				if !errors.As(err, &statusErr) || statusErr.code != tt.wantStatus {
+					t.Errorf("fetch error = %v, want status %d", err, tt.wantStatus)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("fetch error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil || string(body) != "ok":
+				t.Errorf("fetch = (%q, %v), want (\"ok\", nil)", body, err)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("sent %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestFetchStopsCallingFailingHost(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, func(cfg *config.Config) { cfg.Retry.MaxAttempts = 1 })
+	for range 2 {
+		c.fetch(context.Background(), apiWeather, server.URL, nil)
+	}
+
+	_, err := c.fetch(context.Background(), apiWeather, server.URL, nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("fetch error = %v, want ErrCircuitOpen", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("sent %d requests, want 2", got)
+	}
+}
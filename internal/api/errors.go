@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+)
+
+// 外部APIの呼び出しに失敗した理由の分類
+// 呼び出し側はerrors.Isでこれらと比べて、ユーザーへの伝え方を決める
+var (
+	// ErrMissingAPIKey はAPIキーが設定されていない、または接続先に受け付けられなかった時のエラー（管理者の対応が必要）
+	ErrMissingAPIKey = errors.New("api key missing or rejected")
+
+	// ErrQuotaExhausted は外部APIの1日の利用上限に達していて、呼び出しを断った時のエラー（日付が変わるまで待つ必要がある）
+	ErrQuotaExhausted = errors.New("quota exhausted")
+
+	// ErrUpstreamUnavailable は接続先が応答しない・エラーを返した時のエラー（時間をおいて再試行すればよい）
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+
+	// ErrCircuitOpen は失敗が続いている接続先への呼び出しを、しばらくの間止めている時のエラー
+	// ErrUpstreamUnavailableの一種として扱われる
+	ErrCircuitOpen = &circuitOpenError{}
+
+	// ErrNoResults は呼び出しには成功したが、結果が1件もなかった時のエラー（言い直せば見つかるかもしれない）
+	ErrNoResults = errors.New("no results")
+
+	// ErrInvalidInput は入力内容に問題があって呼び出せなかった時のエラー
+	// 詳しい言い直し方はInputErrorのHintで伝える
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+// circuitOpenError はErrCircuitOpenの型
+type circuitOpenError struct{}
+
+func (e *circuitOpenError) Error() string { return "upstream temporarily unavailable" }
+
+// Is はErrCircuitOpenをErrUpstreamUnavailableとしても扱えるようにする
+func (e *circuitOpenError) Is(target error) bool { return target == ErrUpstreamUnavailable }
+
+// InputError は入力内容に問題があった時のエラー
+// errors.Is(err, ErrInvalidInput) で判定できる
+type InputError struct {
+	Hint string // ユーザーへの言い直し方の案内
+}
+
+// invalidInput はhintを案内するInputErrorを作成
+func invalidInput(hint string) error {
+	return &InputError{Hint: hint}
+}
+
+func (e *InputError) Error() string { return "invalid input: " + e.Hint }
+
+// Is はInputErrorをErrInvalidInputとして扱えるようにする
+func (e *InputError) Is(target error) bool { return target == ErrInvalidInput }
+
+// Is は接続先が返したステータスコードを失敗の理由に分類する
+// 401・403はAPIキーの問題、429・5xxは接続先の不調として扱う
+func (e *statusError) Is(target error) bool {
+	switch target {
+	case ErrMissingAPIKey:
+		return e.code == http.StatusUnauthorized || e.code == http.StatusForbidden
+	case ErrUpstreamUnavailable:
+		return e.code == http.StatusTooManyRequests || e.code >= http.StatusInternalServerError
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"strconv"
@@ -20,10 +21,23 @@ type GourmetResponse struct {
 	} `json:"results"`
 }
 
+// defaultGourmetArea は地域が指定されなかった時に探す地域
+const defaultGourmetArea = "渋谷駅"
+
 // GetGourmet searches for restaurants
 func (c *Client) GetGourmet(address, keyword string) (string, error) {
+	return c.GetGourmetContext(context.Background(), address, keyword)
+}
+
+// GetGourmetContext はGetGourmetと同様だが、ctxがキャンセルされるとAPIの呼び出しを中断する
+func (c *Client) GetGourmetContext(ctx context.Context, address, keyword string) (string, error) {
+	cfg := c.cfg()
+	if cfg.RecruitAPIKey == "" {
+		return "", ErrMissingAPIKey
+	}
+
 	if address == "" {
-		address = "渋谷駅"
+		address = defaultGourmetArea
 	}
 
 	// Replace commas with spaces in keyword
@@ -32,21 +46,23 @@ func (c *Client) GetGourmet(address, keyword string) (string, error) {
 		keyword = strings.ReplaceAll(keyword, "、", " ")
 	}
 
-	requestURL := c.buildURL(c.config.HotPepperAPIHost, map[string]string{
-		"key":     c.config.RecruitAPIKey,
+	requestURL := c.buildURL(cfg.HotPepperAPIHost, map[string]string{
+		"key":     cfg.RecruitAPIKey,
 		"address": address,
 		"keyword": keyword,
 		"count":   strconv.Itoa(100),
 		"format":  "json",
 	})
 
+	// 再試行した場合も1回ずつ数える
 	var response GourmetResponse
-	if err := c.makeGetRequest(requestURL, &response); err != nil {
+	reserve := func() error { return c.reserveQuota(UpstreamHotPepper, 1) }
+	if err := c.makeGetRequest(ctx, apiHotPepper, requestURL, &response, reserve); err != nil {
 		return "", fmt.Errorf("failed to get gourmet info: %w", err)
 	}
 
 	if len(response.Results.Shop) == 0 {
-		return "ごめんね、お店見つけられなかったよ……", nil
+		return "", ErrNoResults
 	}
 
 	// Select random shop
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -14,10 +15,21 @@ type ImageSearchResponse struct {
 }
 
 // GetImageSearch は指定されたクエリで画像を検索してランダムな結果を返す
-func (c *Client) GetImageSearch(query string) (string, error) {
+// langには検索に使う言語コード（「ja」など）を指定する
+func (c *Client) GetImageSearch(query, lang string) (string, error) {
+	return c.GetImageSearchContext(context.Background(), query, lang)
+}
+
+// GetImageSearchContext はGetImageSearchと同様だが、ctxがキャンセルされるとAPIの呼び出しを中断する
+func (c *Client) GetImageSearchContext(ctx context.Context, query, lang string) (string, error) {
+	cfg := c.cfg()
+	if cfg.CustomSearchAPIKey == "" || cfg.CustomSearchEngineID == "" {
+		return "", ErrMissingAPIKey
+	}
+
 	// 検索ワードが空の場合
 	if query == "" {
-		return "検索ワードがないよ？ 『/image ねこ』みたいに書いてね！", nil
+		return "", invalidInput("検索ワードがないよ？ 『/image ねこ』みたいに書いてね！")
 	}
 
 	// Ruby版と同様にカンマを空白に置換
@@ -26,25 +38,27 @@ func (c *Client) GetImageSearch(query string) (string, error) {
 
 	// Google Custom Search API画像検索パラメータを構築
 	params := map[string]string{
-		"key":        c.config.CustomSearchAPIKey,
-		"cx":         c.config.CustomSearchEngineID,
+		"key":        cfg.CustomSearchAPIKey,
+		"cx":         cfg.CustomSearchEngineID,
 		"q":          query,
-		"hl":         "ja",    // 言語設定（日本語）
+		"hl":         lang,    // 言語設定
 		"searchType": "image", // 画像検索指定
 		"num":        "10",    // Ruby版と同様に最大10件取得
 	}
 
 	// リクエストURLを構築
-	requestURL := c.buildURL(c.config.CustomSearchAPIHost, params)
+	requestURL := c.buildURL(cfg.CustomSearchAPIHost, params)
 
+	// 再試行した場合も1回ずつ数える
 	var response ImageSearchResponse
-	if err := c.makeGetRequest(requestURL, &response); err != nil {
+	reserve := func() error { return c.reserveQuota(UpstreamCustomSearch, 1) }
+	if err := c.makeGetRequest(ctx, apiCustomSearch, requestURL, &response, reserve); err != nil {
 		return "", fmt.Errorf("画像検索APIの呼び出しに失敗: %w", err)
 	}
 
 	// 検索結果がない場合
 	if len(response.Items) == 0 {
-		return "画像が見つからなかったよー", nil
+		return "", ErrNoResults
 	}
 
 	// Ruby版と同様にランダムに1つの画像を選択
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"net/url"
@@ -15,26 +16,36 @@ type NewsResponse struct {
 
 // GetNews ははてなホットエントリーからランダムなニュースを取得
 func (c *Client) GetNews() (string, error) {
+	return c.GetNewsContext(context.Background())
+}
+
+// GetNewsContext はGetNewsと同様だが、ctxがキャンセルされるとAPIの呼び出しを中断する
+func (c *Client) GetNewsContext(ctx context.Context) (string, error) {
+	cfg := c.cfg()
+	if cfg.RSS2JSONAPIKey == "" {
+		return "", ErrMissingAPIKey
+	}
+
 	// RSSのURLをパーセントエンコードして安全なURL形式に変換
-	encodedRSSURL := url.QueryEscape(c.config.HatenaHotentryRSS)
+	encodedRSSURL := url.QueryEscape(cfg.HatenaHotentryRSS)
 
 	// RSS2JSON APIのリクエストURLを構築
 	// Ruby版と同様に最大50件取得（実際のRSSは30件程度）
 	requestURL := fmt.Sprintf("%s?rss_url=%s&api_key=%s&count=%d",
-		c.config.RSS2JSONAPIHost,
+		cfg.RSS2JSONAPIHost,
 		encodedRSSURL,
-		c.config.RSS2JSONAPIKey,
+		cfg.RSS2JSONAPIKey,
 		50,
 	)
 
 	var response NewsResponse
-	if err := c.makeGetRequest(requestURL, &response); err != nil {
+	if err := c.makeCachedGetRequest(ctx, apiNews, requestURL, &response, nil); err != nil {
 		return "", fmt.Errorf("ニュース取得APIの呼び出しに失敗: %w", err)
 	}
 
 	// 取得した記事がない場合
 	if len(response.Items) == 0 {
-		return "ニュースが取得できませんでした。", nil
+		return "", ErrNoResults
 	}
 
 	// Ruby版と同じようにランダムに1つの記事を選択
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+	_ "time/tzdata" // タイムゾーン情報のないOSでも各APIのリセット時刻を計算できるように埋め込む
+
+	"kizuna_bot_go/internal/store"
+)
+
+// Upstream は利用回数を数える外部APIの種類
+type Upstream string
+
+// 利用回数を数える外部APIの一覧
+const (
+	UpstreamCustomSearch Upstream = "custom_search" // Google カスタム検索API（画像検索）
+	UpstreamYouTube      Upstream = "youtube"       // YouTube Data API（動画検索）
+	UpstreamHotPepper    Upstream = "hotpepper"     // ホットペッパーAPI（グルメ検索）
+)
+
+// youTubeSearchCost はYouTube Data APIのsearch.list 1回で消費するユニット数
+const youTubeSearchCost = 100
+
+// upstreamInfo は外部APIごとの表示名・単位・利用回数がリセットされるタイムゾーン
+type upstreamInfo struct {
+	name     string         // 表示名
+	unit     string         // 利用量の単位
+	location *time.Location // 日付が変わった時に利用回数がリセットされるタイムゾーン
+}
+
+// upstreams は利用回数を数える外部APIを表示順に並べたもの
+var upstreams = []Upstream{UpstreamCustomSearch, UpstreamYouTube, UpstreamHotPepper}
+
+// upstreamInfos はUpstreamごとの情報
+// GoogleのAPIは太平洋時間の0時、ホットペッパーは日本時間の0時を区切りとして数える
+var upstreamInfos = map[Upstream]upstreamInfo{
+	UpstreamCustomSearch: {name: "画像検索（Google カスタム検索）", unit: "回", location: mustLoadLocation("America/Los_Angeles")},
+	UpstreamYouTube:      {name: "動画検索（YouTube Data API）", unit: "ユニット", location: mustLoadLocation("America/Los_Angeles")},
+	UpstreamHotPepper:    {name: "グルメ検索（ホットペッパー）", unit: "回", location: mustLoadLocation("Asia/Tokyo")},
+}
+
+// QuotaStatus は1つの外部APIの今日の利用状況
+type QuotaStatus struct {
+	Upstream Upstream  // 外部APIの種類
+	Name     string    // 表示名
+	Unit     string    // 利用量の単位
+	Used     int       // 今日の利用量
+	Limit    int       // 1日の上限（0は上限なし）
+	ResetAt  time.Time // 次に利用量がリセットされる時刻
+}
+
+// quotaUsage はStoreに保存する1つの外部APIの利用量
+type quotaUsage struct {
+	Period string `json:"period"` // 利用量を数えている日付（リセットのタイムゾーンでの「2006-01-02」）
+	Used   int    `json:"used"`   // その日の利用量
+}
+
+// quotaAccountant は外部APIの利用量を数え、上限に達したら呼び出しを断る
+// 利用量はStoreに保存され、再起動しても引き継がれる
+type quotaAccountant struct {
+	mu    sync.Mutex
+	store store.Store // 利用量の保存先（nilの場合はメモリ上でのみ数える）
+	usage map[Upstream]*quotaUsage
+}
+
+// newQuotaAccountant は保存済みの利用量を読み込んでquotaAccountantを作成
+func newQuotaAccountant(st store.Store) *quotaAccountant {
+	q := &quotaAccountant{
+		store: st,
+		usage: make(map[Upstream]*quotaUsage),
+	}
+
+	for _, upstream := range upstreams {
+		usage := &quotaUsage{}
+		if st != nil {
+			if _, err := st.Get(store.BucketQuotaUsage, string(upstream), usage); err != nil {
+				slog.Error("APIの利用量の読み込みに失敗しました（0から数え直します）", "upstream", upstream, "error", err)
+				usage = &quotaUsage{}
+			}
+		}
+		q.usage[upstream] = usage
+	}
+	return q
+}
+
+// reserve はAPIを呼び出す前に利用量をcost分だけ加算する
+// 加算すると上限を超える場合は加算せずにErrQuotaExhaustedを返す
+func (q *quotaAccountant) reserve(upstream Upstream, cost, limit int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage := q.current(upstream, time.Now())
+	if limit > 0 && usage.Used+cost > limit {
+		return fmt.Errorf("%w: %s (%d/%d)", ErrQuotaExhausted, upstream, usage.Used, limit)
+	}
+
+	usage.Used += cost
+	if q.store != nil {
+		if err := q.store.Put(store.BucketQuotaUsage, string(upstream), usage); err != nil {
+			slog.Error("APIの利用量の保存に失敗しました", "upstream", upstream, "error", err)
+		}
+	}
+	return nil
+}
+
+// status は全ての外部APIの今日の利用状況を返す
+func (q *quotaAccountant) status(limits map[string]int) []QuotaStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	var statuses []QuotaStatus
+	for _, upstream := range upstreams {
+		info := upstreamInfos[upstream]
+		local := now.In(info.location)
+		statuses = append(statuses, QuotaStatus{
+			Upstream: upstream,
+			Name:     info.name,
+			Unit:     info.unit,
+			Used:     q.current(upstream, now).Used,
+			Limit:    limits[string(upstream)],
+			ResetAt:  time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, info.location),
+		})
+	}
+	return statuses
+}
+
+// current は今日の利用量を返す（日付が変わっていれば0に戻す。呼び出し側でロックを取得しておくこと）
+func (q *quotaAccountant) current(upstream Upstream, now time.Time) *quotaUsage {
+	period := now.In(upstreamInfos[upstream].location).Format("2006-01-02")
+
+	usage := q.usage[upstream]
+	if usage.Period != period {
+		usage.Period = period
+		usage.Used = 0
+	}
+	return usage
+}
+
+// reserveQuota はAPIを呼び出す前に利用量を加算し、上限に達している場合はエラーを返す
+func (c *Client) reserveQuota(upstream Upstream, cost int) error {
+	return c.quota.reserve(upstream, cost, c.cfg().Quotas[string(upstream)])
+}
+
+// QuotaStatus は全ての外部APIの今日の利用状況を返す
+func (c *Client) QuotaStatus() []QuotaStatus {
+	return c.quota.status(c.cfg().Quotas)
+}
+
+// mustLoadLocation はタイムゾーンを読み込む（埋め込み済みのため失敗しない）
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
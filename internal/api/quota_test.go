@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"kizuna_bot_go/internal/config"
+)
+
+func TestImageSearchReservesQuotaPerAttempt(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int32 // 最初に503を返す回数
+		limit        int
+		wantErr      error
+		wantRequests int32
+		wantUsed     int
+	}{
+		{name: "成功", failures: 0, limit: 10, wantRequests: 1, wantUsed: 1},
+		{name: "再試行した分も数える", failures: 2, limit: 10, wantRequests: 3, wantUsed: 3},
+		{name: "再試行の途中で上限に達したら送らない", failures: 2, limit: 2, wantErr: ErrQuotaExhausted, wantRequests: 2, wantUsed: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Write([]byte(`{"items":[{"link":"https://example.com/cat.png"}]}`))
+			}))
+			defer server.Close()
+
+			c := newTestClient(t, func(cfg *config.Config) {
+				cfg.CustomSearchAPIHost = server.URL
+				cfg.CustomSearchAPIKey = "key"
+				cfg.CustomSearchEngineID = "cx"
+				cfg.Quotas = map[string]int{string(UpstreamCustomSearch): tt.limit}
+			})
+
+			_, err := c.GetImageSearchContext(context.Background(), "ねこ", "ja")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("error = %v, want nil", err)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("sent %d requests, want %d", got, tt.wantRequests)
+			}
+			if got := c.QuotaStatus()[0].Used; got != tt.wantUsed {
+				t.Errorf("used = %d, want %d", got, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestQuotaExhaustedRefusesLocally(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"items":[{"link":"https://example.com/cat.png"}]}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, func(cfg *config.Config) {
+		cfg.CustomSearchAPIHost = server.URL
+		cfg.CustomSearchAPIKey = "key"
+		cfg.CustomSearchEngineID = "cx"
+		cfg.Quotas = map[string]int{string(UpstreamCustomSearch): 1}
+	})
+
+	if _, err := c.GetImageSearchContext(context.Background(), "ねこ", "ja"); err != nil {
+		t.Fatalf("first search: %v", err)
+	}
+	if _, err := c.GetImageSearchContext(context.Background(), "ねこ", "ja"); !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("second search error = %v, want ErrQuotaExhausted", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("sent %d requests, want 1", got)
+	}
+}
+
+func TestQuotaUpstreamsMatchConfig(t *testing.T) {
+	// quotasに書けるキーは、利用回数を数える外部APIと同じでなければならない
+	var names []string
+	for _, upstream := range upstreams {
+		names = append(names, string(upstream))
+	}
+	if !slices.Equal(names, config.QuotaUpstreams) {
+		t.Errorf("upstreams = %v, config.QuotaUpstreams = %v", names, config.QuotaUpstreams)
+	}
+}
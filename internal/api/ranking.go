@@ -1,11 +1,13 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"sort"
 
 	"github.com/bwmarrin/discordgo"
+	"kizuna_bot_go/internal/messenger"
 )
 
 // UserRankEntry はユーザーランキングの1エントリを表す構造体
@@ -15,9 +17,15 @@ type UserRankEntry struct {
 }
 
 // GetUserRanking は指定されたチャンネルでのユーザーアクティビティランキングを生成
-func (c *Client) GetUserRanking(session *discordgo.Session, channelID string) (string, error) {
-	maxCount := c.config.RankTotalCount // 設定で指定された件数（200件）
-	maxPerPage := 100                   // Discord APIの制限：1回のリクエストで最大100件
+// メッセージ履歴はMessenger経由で取得するため、Discordに接続していなくても動作する
+func (c *Client) GetUserRanking(history messenger.Messenger, channelID string) (string, error) {
+	return c.GetUserRankingContext(context.Background(), history, channelID)
+}
+
+// GetUserRankingContext はGetUserRankingと同様だが、ctxがキャンセルされるとAPIの呼び出しを中断する
+func (c *Client) GetUserRankingContext(ctx context.Context, history messenger.Messenger, channelID string) (string, error) {
+	maxCount := c.cfg().RankTotalCount // 設定で指定された件数（200件）
+	maxPerPage := 100                  // Discord APIの制限：1回のリクエストで最大100件
 
 	var allMessages []*discordgo.Message
 	beforeID := ""
@@ -31,10 +39,15 @@ func (c *Client) GetUserRanking(session *discordgo.Session, channelID string) (s
 			limit = maxPerPage
 		}
 
+		// 集計の途中で中断された場合は、残りのページを取得しない
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
 		// Discord APIでメッセージを取得
-		messages, err := session.ChannelMessages(channelID, limit, beforeID, "", "")
+		messages, err := history.History(channelID, limit, beforeID)
 		if err != nil {
-			return "", fmt.Errorf("チャンネルメッセージの取得に失敗: %w", err)
+			return "", fmt.Errorf("チャンネルメッセージの取得に失敗: %w: %w", ErrUpstreamUnavailable, err)
 		}
 
 		// メッセージがない場合は終了
@@ -55,6 +68,11 @@ func (c *Client) GetUserRanking(session *discordgo.Session, channelID string) (s
 		}
 	}
 
+	// 集計するメッセージがない場合
+	if len(allMessages) == 0 {
+		return "", ErrNoResults
+	}
+
 	// ユーザー別の投稿数をカウント
 	userPostCount := make(map[string]int)
 	for _, message := range allMessages {
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 )
@@ -13,8 +14,15 @@ type TranslateResponse struct {
 
 // GetTranslation は指定されたテキストを翻訳
 func (c *Client) GetTranslation(text, targetLang string) (string, error) {
+	return c.GetTranslationContext(context.Background(), text, targetLang)
+}
+
+// GetTranslationContext はGetTranslationと同様だが、ctxがキャンセルされるとAPIの呼び出しを中断する
+func (c *Client) GetTranslationContext(ctx context.Context, text, targetLang string) (string, error) {
+	cfg := c.cfg()
+
 	if text == "" {
-		return "翻訳するテキストを入力してね！", nil
+		return "", invalidInput("翻訳するテキストを入力してね！")
 	}
 
 	// デフォルトは英語
@@ -26,36 +34,20 @@ func (c *Client) GetTranslation(text, targetLang string) (string, error) {
 	values := url.Values{}
 	values.Set("text", text)
 	values.Set("target", targetLang)
-	requestURL := c.config.GoogleTranslateAPIHost + "?" + values.Encode()
+	requestURL := cfg.GoogleTranslateAPIHost + "?" + values.Encode()
 
-	// まずは単純なHTTPリクエストを試行
-	resp, err := c.httpClient.Get(requestURL)
+	// 翻訳結果はJSONではなくテキストがそのまま返るため、レスポンスボディをそのまま使う
+	// 401・403（Ruby版で発生している認証エラーの可能性）はErrMissingAPIKey、5xxはErrUpstreamUnavailableとして扱われる
+	body, err := c.fetch(ctx, apiTranslate, requestURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("翻訳APIへのリクエストに失敗: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// ステータスコードをチェック
-	if resp.StatusCode != 200 {
-		// Ruby版で発生している認証エラーの可能性
-		if resp.StatusCode == 401 || resp.StatusCode == 403 {
-			return "申し訳ありません。翻訳機能は現在メンテナンス中です。しばらく時間をおいてからお試しください。", nil
-		}
-		return "", fmt.Errorf("翻訳API呼び出しでエラー（ステータス: %d）", resp.StatusCode)
-	}
-
-	// レスポンスボディを読み取り
-	body := make([]byte, 1024*10) // 最大10KB
-	n, err := resp.Body.Read(body)
-	if err != nil && err.Error() != "EOF" {
-		return "", fmt.Errorf("翻訳APIレスポンスの読み取りに失敗: %w", err)
+		return "", fmt.Errorf("翻訳API呼び出しでエラー: %w", err)
 	}
 
-	translatedText := string(body[:n])
+	translatedText := string(body)
 
 	// 空のレスポンスの場合
 	if translatedText == "" {
-		return "翻訳に失敗しました。テキストが翻訳できない形式の可能性があります。", nil
+		return "", invalidInput("うまく翻訳できなかったよ……翻訳できない形式のテキストかも？")
 	}
 
 	// Ruby版と同じメッセージ形式で返却
@@ -67,6 +59,11 @@ func (c *Client) GetTranslation(text, targetLang string) (string, error) {
 
 // GetTranslationWithQuotes は「」で囲まれたテキストを抽出して翻訳
 func (c *Client) GetTranslationWithQuotes(content, targetLang string) (string, error) {
+	return c.GetTranslationWithQuotesContext(context.Background(), content, targetLang)
+}
+
+// GetTranslationWithQuotesContext はGetTranslationWithQuotesと同様だが、ctxがキャンセルされるとAPIの呼び出しを中断する
+func (c *Client) GetTranslationWithQuotesContext(ctx context.Context, content, targetLang string) (string, error) {
 	// 「」で囲まれたテキストを抽出（正規表現を使用せずシンプルに）
 	startIndex := -1
 	endIndex := -1
@@ -84,13 +81,13 @@ func (c *Client) GetTranslationWithQuotes(content, targetLang string) (string, e
 	// 「」が見つからない場合
 	if startIndex == -1 || endIndex == -1 {
 		if targetLang == "en" {
-			return "「」で囲ってくれると英語に翻訳するよ〜", nil
+			return "", invalidInput("「」で囲ってくれると英語に翻訳するよ〜")
 		} else {
-			return "「」で囲ってくれると日本語に翻訳するよ〜", nil
+			return "", invalidInput("「」で囲ってくれると日本語に翻訳するよ〜")
 		}
 	}
 
 	// 抽出したテキストを翻訳
 	extractedText := string(runes[startIndex:endIndex])
-	return c.GetTranslation(extractedText, targetLang)
+	return c.GetTranslationContext(ctx, extractedText, targetLang)
 }
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"kizuna_bot_go/internal/config"
+)
+
+func TestGetTranslationRetriesAndStopsFailingHost(t *testing.T) {
+	var requests atomic.Int32
+	var failures atomic.Int32
+	failures.Store(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("Hello"))
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, func(cfg *config.Config) { cfg.GoogleTranslateAPIHost = server.URL })
+
+	// 1回目の失敗は再試行で取り戻す
+	message, err := c.GetTranslationContext(context.Background(), "こんにちは", "en")
+	if err != nil || !strings.Contains(message, "「Hello」") {
+		t.Fatalf("GetTranslationContext = (%q, %v)", message, err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("sent %d requests, want 2", got)
+	}
+
+	// 失敗が続くと、翻訳APIへの呼び出しも止める
+	failures.Store(1 << 30)
+	for range 2 {
+		if _, err := c.GetTranslationContext(context.Background(), "こんにちは", "en"); !errors.Is(err, ErrUpstreamUnavailable) {
+			t.Fatalf("GetTranslationContext error = %v, want ErrUpstreamUnavailable", err)
+		}
+	}
+	if _, err := c.GetTranslationContext(context.Background(), "こんにちは", "en"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("GetTranslationContext error = %v, want ErrCircuitOpen", err)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 )
@@ -15,10 +16,21 @@ type YouTubeSearchResponse struct {
 }
 
 // GetVideoByQuery は検索クエリを使ってYouTube動画をランダムに取得
-func (c *Client) GetVideoByQuery(query string) (string, error) {
+// langを指定すると、その言語に関連性の高い動画を優先する
+func (c *Client) GetVideoByQuery(query, lang string) (string, error) {
+	return c.GetVideoByQueryContext(context.Background(), query, lang)
+}
+
+// GetVideoByQueryContext はGetVideoByQueryと同様だが、ctxがキャンセルされるとAPIの呼び出しを中断する
+func (c *Client) GetVideoByQueryContext(ctx context.Context, query, lang string) (string, error) {
+	cfg := c.cfg()
+	if cfg.YouTubeDataAPIKey == "" {
+		return "", ErrMissingAPIKey
+	}
+
 	// YouTube Data API検索パラメータを構築
 	params := map[string]string{
-		"key":        c.config.YouTubeDataAPIKey,
+		"key":        cfg.YouTubeDataAPIKey,
 		"part":       "id",
 		"type":       "video",
 		"maxResults": "50",
@@ -30,18 +42,21 @@ func (c *Client) GetVideoByQuery(query string) (string, error) {
 	if query != "" {
 		params["q"] = query
 	}
+	if lang != "" {
+		params["relevanceLanguage"] = lang
+	}
 
 	// リクエストURLを構築
-	requestURL := c.buildURL(c.config.YouTubeDataAPIHost+"/search", params)
+	requestURL := c.buildURL(cfg.YouTubeDataAPIHost+"/search", params)
 
 	var response YouTubeSearchResponse
-	if err := c.makeGetRequest(requestURL, &response); err != nil {
+	if err := c.makeCachedGetRequest(ctx, apiYouTube, requestURL, &response, c.reserveYouTubeSearch); err != nil {
 		return "", fmt.Errorf("YouTube動画検索APIの呼び出しに失敗: %w", err)
 	}
 
 	// 検索結果がない場合
 	if len(response.Items) == 0 {
-		return "", fmt.Errorf("動画が見つかりませんでした")
+		return "", fmt.Errorf("動画が見つかりませんでした: %w", ErrNoResults)
 	}
 
 	// Ruby版と同様にランダムに1つの動画を選択
@@ -54,13 +69,23 @@ func (c *Client) GetVideoByQuery(query string) (string, error) {
 
 // GetVideoByChannel は指定されたチャンネルIDから動画をランダムに取得
 func (c *Client) GetVideoByChannel(channelID string) (string, error) {
+	return c.GetVideoByChannelContext(context.Background(), channelID)
+}
+
+// GetVideoByChannelContext はGetVideoByChannelと同様だが、ctxがキャンセルされるとAPIの呼び出しを中断する
+func (c *Client) GetVideoByChannelContext(ctx context.Context, channelID string) (string, error) {
+	cfg := c.cfg()
+	if cfg.YouTubeDataAPIKey == "" {
+		return "", ErrMissingAPIKey
+	}
+
 	if channelID == "" {
-		return "", fmt.Errorf("チャンネルIDが指定されていません")
+		return "", invalidInput("チャンネルIDが指定されていないよ")
 	}
 
 	// YouTube Data API検索パラメータを構築
 	params := map[string]string{
-		"key":        c.config.YouTubeDataAPIKey,
+		"key":        cfg.YouTubeDataAPIKey,
 		"part":       "id",
 		"type":       "video",
 		"channelId":  channelID,
@@ -69,16 +94,16 @@ func (c *Client) GetVideoByChannel(channelID string) (string, error) {
 	}
 
 	// リクエストURLを構築
-	requestURL := c.buildURL(c.config.YouTubeDataAPIHost+"/search", params)
+	requestURL := c.buildURL(cfg.YouTubeDataAPIHost+"/search", params)
 
 	var response YouTubeSearchResponse
-	if err := c.makeGetRequest(requestURL, &response); err != nil {
+	if err := c.makeCachedGetRequest(ctx, apiYouTube, requestURL, &response, c.reserveYouTubeSearch); err != nil {
 		return "", fmt.Errorf("YouTubeチャンネル動画検索APIの呼び出しに失敗: %w", err)
 	}
 
 	// 検索結果がない場合
 	if len(response.Items) == 0 {
-		return "", fmt.Errorf("チャンネルから動画が見つかりませんでした")
+		return "", fmt.Errorf("チャンネルから動画が見つかりませんでした: %w", ErrNoResults)
 	}
 
 	// Ruby版と同様にランダムに1つの動画を選択
@@ -89,12 +114,23 @@ func (c *Client) GetVideoByChannel(channelID string) (string, error) {
 	return fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID), nil
 }
 
+// reserveYouTubeSearch はYouTube Data APIで検索する前に利用量を加算する
+// キャッシュを使った場合は呼ばれないため利用量は増えず、再試行した場合は1回ずつ加算する
+func (c *Client) reserveYouTubeSearch() error {
+	return c.reserveQuota(UpstreamYouTube, youTubeSearchCost)
+}
+
 // GetVideoSearch は検索クエリに基づいて動画検索メッセージを生成
-func (c *Client) GetVideoSearch(query string) (string, error) {
+func (c *Client) GetVideoSearch(query, lang string) (string, error) {
+	return c.GetVideoSearchContext(context.Background(), query, lang)
+}
+
+// GetVideoSearchContext はGetVideoSearchと同様だが、ctxがキャンセルされるとAPIの呼び出しを中断する
+func (c *Client) GetVideoSearchContext(ctx context.Context, query, lang string) (string, error) {
 	// YouTube動画を検索
-	videoURL, err := c.GetVideoByQuery(query)
+	videoURL, err := c.GetVideoByQueryContext(ctx, query, lang)
 	if err != nil {
-		return "いい動画が見つけられなかったよ、ごめんね", nil
+		return "", err
 	}
 
 	// Ruby版と同じメッセージ形式で返却
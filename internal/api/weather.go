@@ -1,71 +1,230 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"kizuna_bot_go/internal/textutil"
 )
 
-// WeatherResponse represents the weather API response
+// WeatherResponse は天気予報APIのレスポンス構造体
 type WeatherResponse struct {
-	Location struct {
-		City string `json:"city"`
+	Title       string `json:"title"` // 「東京都 東京 の天気」のような見出し
+	Description struct {
+		HeadlineText string `json:"headlineText"` // 天気概況の見出し（ない場合もある）
+		BodyText     string `json:"bodyText"`     // 天気概況の本文
+		Text         string `json:"text"`         // 見出しと本文をまとめたもの
+	} `json:"description"`
+	Forecasts []WeatherForecast `json:"forecasts"` // 今日・明日・明後日の予報
+	Location  struct {
+		Prefecture string `json:"prefecture"` // 都道府県名
+		City       string `json:"city"`       // 地域名
 	} `json:"location"`
-	Forecasts []struct {
-		DateLabel   string `json:"dateLabel"`
-		Date        string `json:"date"`
-		Telop       string `json:"telop"`
-		Temperature struct {
-			Max *struct {
-				Celsius string `json:"celsius"`
-			} `json:"max"`
-		} `json:"temperature"`
-	} `json:"forecasts"`
 	PinpointLocations []struct {
-		Name string `json:"name"`
-		Link string `json:"link"`
+		Name string `json:"name"` // 市区町村名
+		Link string `json:"link"` // 市区町村ごとの詳しい天気予報のページ
 	} `json:"pinpointLocations"`
 }
 
-// GetWeather fetches weather information for Tokyo
+// WeatherForecast は1日分の天気予報
+type WeatherForecast struct {
+	Date      string `json:"date"`      // 日付（2006-01-02）
+	DateLabel string `json:"dateLabel"` // 「今日」「明日」「明後日」
+	Telop     string `json:"telop"`     // 「晴時々曇」のような天気
+	Detail    struct {
+		Weather string `json:"weather"` // 詳しい天気
+		Wind    string `json:"wind"`    // 風の強さ・向き
+		Wave    string `json:"wave"`    // 波の高さ
+	} `json:"detail"`
+	Temperature struct {
+		Min WeatherTemperature `json:"min"` // 最低気温
+		Max WeatherTemperature `json:"max"` // 最高気温
+	} `json:"temperature"`
+	ChanceOfRain ChanceOfRain `json:"chanceOfRain"` // 6時間ごとの降水確率
+}
+
+// WeatherTemperature は気温（発表されていない場合はnullになるため空文字列）
+type WeatherTemperature struct {
+	Celsius string `json:"celsius"` // 摂氏
+}
+
+// ChanceOfRain は6時間ごとの降水確率（「10%」のような形式。発表されていない時間帯は「--%」）
+type ChanceOfRain struct {
+	T00To06 string `json:"T00_06"`
+	T06To12 string `json:"T06_12"`
+	T12To18 string `json:"T12_18"`
+	T18To24 string `json:"T18_24"`
+}
+
+// Periods は時間帯の表示名と降水確率を、時間の順に返す
+func (c ChanceOfRain) Periods() []struct{ Label, Value string } {
+	return []struct{ Label, Value string }{
+		{"0-6時", c.T00To06},
+		{"6-12時", c.T06To12},
+		{"12-18時", c.T12To18},
+		{"18-24時", c.T18To24},
+	}
+}
+
+// Max は発表されている時間帯の中で最も高い降水確率を返す（どの時間帯も発表されていない場合はokがfalse）
+func (c ChanceOfRain) Max() (percent int, ok bool) {
+	for _, period := range c.Periods() {
+		value, err := strconv.Atoi(strings.TrimSuffix(period.Value, "%"))
+		if err != nil {
+			continue
+		}
+		if !ok || value > percent {
+			percent, ok = value, true
+		}
+	}
+	return percent, ok
+}
+
+// WeatherOptions は天気予報のメッセージの作り方
+type WeatherOptions struct {
+	Detail bool   // 時間帯ごとの降水確率・風・波・天気概況も含める
+	Place  string // 詳しい天気予報のリンクを選ぶための住所（例: 東京都新宿区）。合う市区町村がなければ最初の市区町村
+}
+
+// descriptionMaxRunes は詳しい天気予報に含める天気概況の最大文字数（Discordのメッセージの長さ制限を超えないように）
+const descriptionMaxRunes = 500
+
+// GetWeather は設定ファイルで指定された地域（既定は東京）の天気予報を取得
 func (c *Client) GetWeather() (string, error) {
-	url := c.buildURL(c.config.LivedoorWeatherAPIHost, map[string]string{
-		"city": strconv.Itoa(c.config.TokyoCityID),
+	return c.GetWeatherContext(context.Background())
+}
+
+// GetWeatherContext はGetWeatherと同様だが、ctxがキャンセルされるとAPIの呼び出しを中断する
+func (c *Client) GetWeatherContext(ctx context.Context) (string, error) {
+	return c.GetWeatherByCityContext(ctx, c.cfg().TokyoCityID)
+}
+
+// GetWeatherByCity は指定された都市IDの地域の天気予報を取得
+func (c *Client) GetWeatherByCity(cityID int) (string, error) {
+	return c.GetWeatherByCityContext(context.Background(), cityID)
+}
+
+// GetWeatherByCityContext はGetWeatherByCityと同様だが、ctxがキャンセルされるとAPIの呼び出しを中断する
+func (c *Client) GetWeatherByCityContext(ctx context.Context, cityID int) (string, error) {
+	return c.GetWeatherReportContext(ctx, cityID, WeatherOptions{})
+}
+
+// GetWeatherReportContext は指定された都市IDの地域の天気予報を、optsに従ったメッセージにして返す
+func (c *Client) GetWeatherReportContext(ctx context.Context, cityID int, opts WeatherOptions) (string, error) {
+	response, err := c.GetForecastContext(ctx, cityID)
+	if err != nil {
+		return "", err
+	}
+	return formatWeather(response, opts), nil
+}
+
+// GetForecastContext は指定された都市IDの地域の天気予報のレスポンスをそのまま返す
+func (c *Client) GetForecastContext(ctx context.Context, cityID int) (*WeatherResponse, error) {
+	url := c.buildURL(c.cfg().LivedoorWeatherAPIHost, map[string]string{
+		"city": fmt.Sprintf("%06d", cityID),
 	})
 
 	var response WeatherResponse
-	if err := c.makeGetRequest(url, &response); err != nil {
-		return "", fmt.Errorf("failed to get weather: %w", err)
+	if err := c.makeCachedGetRequest(ctx, apiWeather, url, &response, nil); err != nil {
+		return nil, fmt.Errorf("failed to get weather: %w", err)
 	}
-
-	// Find Shibuya link
-	shibuyaLink := ""
-	for _, location := range response.PinpointLocations {
-		if location.Name == "渋谷区" {
-			shibuyaLink = location.Link
-			break
-		}
+	if len(response.Forecasts) == 0 {
+		return nil, fmt.Errorf("天気予報が含まれていませんでした: %w", ErrNoResults)
 	}
+	return &response, nil
+}
 
-	// Build message
+// formatWeather は天気予報のレスポンスをメッセージにする
+func formatWeather(response *WeatherResponse, opts WeatherOptions) string {
 	message := ""
 	for _, forecast := range response.Forecasts {
-		maxTemp := ""
-		if forecast.Temperature.Max != nil {
-			maxTemp = forecast.Temperature.Max.Celsius
-		}
-
 		message += fmt.Sprintf("%s（%s）の%sの天気は「%s」",
 			forecast.DateLabel, forecast.Date, response.Location.City, forecast.Telop)
 
-		if maxTemp != "" {
+		if maxTemp := forecast.Temperature.Max.Celsius; maxTemp != "" {
 			message += fmt.Sprintf("、最高気温は%s℃", maxTemp)
 		}
+		if minTemp := forecast.Temperature.Min.Celsius; minTemp != "" {
+			message += fmt.Sprintf("、最低気温は%s℃", minTemp)
+		}
+		if percent, ok := forecast.ChanceOfRain.Max(); ok {
+			message += fmt.Sprintf("、降水確率は最大%d%%", percent)
+		}
 		message += "\n"
+
+		if opts.Detail {
+			message += formatForecastDetail(forecast)
+		}
 	}
 
-	if shibuyaLink != "" {
-		message += fmt.Sprintf("詳しくはこちら → %s", shibuyaLink)
+	if opts.Detail {
+		description := response.Description.BodyText
+		if description == "" {
+			description = response.Description.Text
+		}
+		if description != "" {
+			message += fmt.Sprintf("\n%s\n", textutil.Truncate(strings.TrimSpace(description), descriptionMaxRunes))
+		}
 	}
 
-	return message, nil
+	if link := pinpointLink(response, opts.Place); link != "" {
+		message += fmt.Sprintf("詳しくはこちら → %s", link)
+	}
+
+	return strings.TrimSuffix(message, "\n")
+}
+
+// formatForecastDetail は1日分の時間帯ごとの降水確率・詳しい天気・風・波をメッセージにする
+func formatForecastDetail(forecast WeatherForecast) string {
+	var periods []string
+	for _, period := range forecast.ChanceOfRain.Periods() {
+		if period.Value != "" {
+			periods = append(periods, fmt.Sprintf("%s %s", period.Label, period.Value))
+		}
+	}
+
+	detail := ""
+	if len(periods) > 0 {
+		detail += fmt.Sprintf("　降水確率: %s\n", strings.Join(periods, " / "))
+	}
+	if forecast.Detail.Weather != "" {
+		detail += fmt.Sprintf("　天気: %s\n", forecast.Detail.Weather)
+	}
+	if forecast.Detail.Wind != "" {
+		detail += fmt.Sprintf("　風: %s\n", forecast.Detail.Wind)
+	}
+	if forecast.Detail.Wave != "" {
+		detail += fmt.Sprintf("　波: %s\n", forecast.Detail.Wave)
+	}
+	return detail
+}
+
+// pinpointLink は詳しい天気予報のリンクに使う市区町村のページを選ぶ
+// placeに含まれる市区町村（「新宿」のように区や市を省いたものも含む）を優先し、なければ最初の市区町村を使う
+func pinpointLink(response *WeatherResponse, place string) string {
+	if len(response.PinpointLocations) == 0 {
+		return ""
+	}
+
+	if place != "" {
+		best, bestLen := "", 0
+		for _, location := range response.PinpointLocations {
+			name := location.Name
+			// 「津市」の「津」のような1文字だけでは他の地名と区別できないため、区や市を省かない
+			base := strings.TrimRight(name, "区市町村")
+			if utf8.RuneCountInString(base) < 2 {
+				base = name
+			}
+			if (strings.Contains(place, name) || strings.Contains(place, base)) && len(name) > bestLen {
+				best, bestLen = location.Link, len(name)
+			}
+		}
+		if best != "" {
+			return best
+		}
+	}
+	return response.PinpointLocations[0].Link
 }
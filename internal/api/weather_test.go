@@ -0,0 +1,120 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChanceOfRainMax(t *testing.T) {
+	tests := []struct {
+		name        string
+		chance      ChanceOfRain
+		wantPercent int
+		wantOK      bool
+	}{
+		{name: "最も高い時間帯", chance: ChanceOfRain{"10%", "30%", "50%", "20%"}, wantPercent: 50, wantOK: true},
+		{name: "発表されていない時間帯は除く", chance: ChanceOfRain{"--%", "--%", "0%", "10%"}, wantPercent: 10, wantOK: true},
+		{name: "0%だけでも発表あり", chance: ChanceOfRain{"--%", "--%", "--%", "0%"}, wantPercent: 0, wantOK: true},
+		{name: "どの時間帯も発表されていない", chance: ChanceOfRain{"--%", "--%", "--%", "--%"}, wantOK: false},
+		{name: "空", chance: ChanceOfRain{}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			percent, ok := tt.chance.Max()
+			if percent != tt.wantPercent || ok != tt.wantOK {
+				t.Errorf("Max() = (%d, %v), want (%d, %v)", percent, ok, tt.wantPercent, tt.wantOK)
+			}
+		})
+	}
+}
+
+// newWeatherResponse はテスト用の東京の天気予報のレスポンスを作成
+func newWeatherResponse(forecasts ...WeatherForecast) *WeatherResponse {
+	response := &WeatherResponse{Forecasts: forecasts}
+	response.Location.City = "東京"
+	return response
+}
+
+// newForecast はテスト用の1日分の予報を作成
+func newForecast(label, date, telop, max, min string, chance ChanceOfRain) WeatherForecast {
+	f := WeatherForecast{Date: date, DateLabel: label, Telop: telop, ChanceOfRain: chance}
+	f.Temperature.Max.Celsius = max
+	f.Temperature.Min.Celsius = min
+	return f
+}
+
+func TestFormatWeather(t *testing.T) {
+	withDetail := newWeatherResponse(newForecast("今日", "2026-10-18", "雨", "20", "15", ChanceOfRain{"--%", "60%", "80%", "40%"}))
+	withDetail.Forecasts[0].Detail.Wind = "北の風"
+	withDetail.Description.BodyText = strings.Repeat("あ", descriptionMaxRunes+10)
+	withDetail.PinpointLocations = []struct {
+		Name string `json:"name"`
+		Link string `json:"link"`
+	}{
+		{Name: "千代田区", Link: "https://example.com/chiyoda"},
+		{Name: "新宿区", Link: "https://example.com/shinjuku"},
+	}
+
+	tests := []struct {
+		name     string
+		response *WeatherResponse
+		opts     WeatherOptions
+		want     string   // 空でなければメッセージ全体と比べる
+		contains []string // wantが空の場合に含まれるべき文字列
+		excludes []string
+	}{
+		{
+			name: "気温と降水確率",
+			response: newWeatherResponse(
+				newForecast("今日", "2026-10-18", "晴れ", "25", "", ChanceOfRain{"--%", "10%", "20%", "0%"}),
+				newForecast("明日", "2026-10-19", "曇り", "22", "14", ChanceOfRain{"30%", "40%", "50%", "30%"}),
+			),
+			want: "今日（2026-10-18）の東京の天気は「晴れ」、最高気温は25℃、降水確率は最大20%\n" +
+				"明日（2026-10-19）の東京の天気は「曇り」、最高気温は22℃、最低気温は14℃、降水確率は最大50%",
+		},
+		{
+			name:     "降水確率が発表されていない日は書かない",
+			response: newWeatherResponse(newForecast("明後日", "2026-10-20", "晴時々曇", "", "", ChanceOfRain{"--%", "--%", "--%", "--%"})),
+			want:     "明後日（2026-10-20）の東京の天気は「晴時々曇」",
+		},
+		{
+			name:     "詳しい天気予報",
+			response: withDetail,
+			opts:     WeatherOptions{Detail: true, Place: "東京都新宿区"},
+			contains: []string{
+				"降水確率は最大80%",
+				"　降水確率: 0-6時 --% / 6-12時 60% / 12-18時 80% / 18-24時 40%",
+				"　風: 北の風",
+				strings.Repeat("あ", descriptionMaxRunes-1) + "…",
+				"詳しくはこちら → https://example.com/shinjuku",
+			},
+			excludes: []string{strings.Repeat("あ", descriptionMaxRunes)},
+		},
+		{
+			name:     "詳しくない時は天気概況を含めない",
+			response: withDetail,
+			contains: []string{"詳しくはこちら → https://example.com/chiyoda"},
+			excludes: []string{"風:", "あああ"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatWeather(tt.response, tt.opts)
+			if tt.want != "" && got != tt.want {
+				t.Errorf("formatWeather() =\n%s\nwant\n%s", got, tt.want)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("message does not contain %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(got, s) {
+					t.Errorf("message contains %q:\n%s", s, got)
+				}
+			}
+		})
+	}
+}
@@ -1,29 +1,56 @@
 package bot
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"kizuna_bot_go/internal/api"
 	"kizuna_bot_go/internal/config"
+	"kizuna_bot_go/internal/logging"
+	"kizuna_bot_go/internal/messenger"
+	"kizuna_bot_go/internal/ratelimit"
+	"kizuna_bot_go/internal/store"
+	"kizuna_bot_go/internal/textutil"
 )
 
 // KizunaBot はDiscordボットのメイン構造体
 // Discordサーバーとの通信、設定管理、外部API呼び出しの機能を持つ
 type KizunaBot struct {
-	session   *discordgo.Session // Discord APIとの通信セッション
-	config    *config.Config     // ボットの設定情報
-	apiClient *api.Client        // 外部API呼び出し用のクライアント
+	session   *discordgo.Session            // Discord APIとの通信セッション
+	messenger messenger.Messenger           // メッセージの送信や履歴取得に使う送信先
+	config    atomic.Pointer[config.Config] // ボットの設定情報（SIGHUPによる再読み込みで差し替わる）
+	apiClient *api.Client                   // 外部API呼び出し用のクライアント
+	store     store.Store                   // ボットの状態の保存先
+	commands  *commandRegistry              // 利用可能なコマンドの一覧
+	limiter   *ratelimit.Limiter            // コマンドの連続実行を制限するレートリミッター
+	handlers  *handlerTracker               // 実行中のイベントハンドラー（終了時に完了を待つ）
+	monitor   *http.Server                  // 監視用のHTTPサーバー（設定で無効の場合はnil）
+	gateway   gatewayState                  // ゲートウェイとの接続状態（ヘルスチェック用）
+
+	// 天気予報の定期配信の設定の読み書きを直列にする（設定の変更と、配信した日付の保存が上書きし合わないように）
+	scheduleMu     sync.Mutex
+	schedulePosted map[string]string // チャンネルIDごとの、このプロセスで最後に配信した日付（保存に失敗した時の二重配信を防ぐ）
+
+	// 雨のお知らせの設定の読み書きを直列にする（設定の変更と、定期的な確認の結果の保存が上書きし合わないように）
+	rainAlertMu sync.Mutex
+
+	// ギルド設定の変更（/config と /perm）を直列にする（同時に変更した時に、片方の変更が消えないように）
+	guildSettingsMu sync.Mutex
+
+	// メッセージごとのコンテキストの親（Closeでキャンセルし、実行中の外部API呼び出しを中断する）
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // NewKizunaBot は新しいKizunaBotインスタンスを作成
-func NewKizunaBot() (*KizunaBot, error) {
-	// 設定を環境変数から読み込み
-	cfg := config.NewConfig()
-
+func NewKizunaBot(cfg *config.Config) (*KizunaBot, error) {
 	// DiscordのBotトークンが設定されていない場合はエラー
 	if cfg.BotToken == "" {
 		return nil, fmt.Errorf("BOT_TOKEN is required")
@@ -37,70 +64,387 @@ func NewKizunaBot() (*KizunaBot, error) {
 	}
 
 	// KizunaBotインスタンスを作成し、必要な構成要素を設定
-	bot := &KizunaBot{
-		session:   session,
-		config:    cfg,
-		apiClient: api.NewClient(cfg),
+	bot, err := newKizunaBot(cfg, messenger.NewDiscord(session))
+	if err != nil {
+		return nil, err
 	}
+	bot.session = session
 
 	// Discordからメッセージ内容を受信するためのIntent（権限）を設定
 	// これにより、ボットがメッセージの内容を読み取れるようになる
-	session.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentsMessageContent
+	// ギルドの情報（ロールなど）は /config などの権限の確認に使う
+	session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentsMessageContent
 
 	// イベントハンドラーを登録
-	session.AddHandler(bot.messageCreate) // メッセージが投稿された時の処理
-	session.AddHandler(bot.ready)         // ボットがDiscordに接続完了した時の処理
+	session.AddHandler(bot.messageCreate)     // メッセージが投稿された時の処理
+	session.AddHandler(bot.ready)             // ボットがDiscordに接続完了した時の処理
+	session.AddHandler(bot.interactionCreate) // アプリケーションコマンドが実行された時の処理
+	session.AddHandler(bot.connect)           // ゲートウェイに接続した時の処理
+	session.AddHandler(bot.resumed)           // 切れた接続を再開できた時の処理
+	session.AddHandler(bot.disconnect)        // ゲートウェイとの接続が切れた時の処理
+	session.AddHandler(bot.event)             // 全てのイベントで、最後に受け取った時刻を記録
 
 	return bot, nil
 }
 
+// newKizunaBot はDiscordへの接続に依存しない構成要素を組み立ててKizunaBotを作成
+// Discordセッションが必要な場合は呼び出し側で設定する
+func newKizunaBot(cfg *config.Config, msgr messenger.Messenger) (*KizunaBot, error) {
+	// ボットの状態を保存するデータベースを開く
+	db, err := store.Open(cfg.DatabasePath)
+	if err != nil {
+		return nil, err
+	}
+
+	bot := &KizunaBot{
+		messenger: msgr,
+		apiClient: api.NewClient(cfg, db),
+		store:     db,
+		commands:  newCommandRegistry(),
+		limiter:   ratelimit.New(),
+		handlers:  newHandlerTracker(),
+
+		schedulePosted: make(map[string]string),
+	}
+	bot.config.Store(cfg)
+	bot.ctx, bot.cancel = context.WithCancel(context.Background())
+	// 起動直後の接続を待つ間に /healthz が失敗しないよう、起動時刻から数える
+	bot.gateway.changedAt.Store(time.Now().UnixNano())
+	bot.registerCommands()
+	bot.reportDisabledFeatures()
+	return bot, nil
+}
+
+// registerCommands はボットが受け付ける全てのコマンドをレジストリに登録
+// ここでの登録順が /help の表示順になる
+func (b *KizunaBot) registerCommands() {
+	commands := []*simpleCommand{
+		{
+			name:        "weather",
+			description: "天気を教えるよ〜 「/weather 大阪」みたいに地名を付けると、その場所の天気を調べるよ。「/weather detail」で詳しく教えるね。「/weather schedule 07:00 大阪」で毎日このチャンネルにお知らせするよ。「/weather alert 大阪」なら雨になりそうな時に教えるね :white_sun_small_cloud:",
+			usage:       "[detail|schedule|alert] [時刻] [地名]",
+			options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "detail: 降水確率の内訳や風・波も教える、schedule: 毎日の配信を設定する、alert: 雨のお知らせを設定する",
+					Choices:     stringChoices(weatherDetail, weatherSchedule, weatherAlert),
+				},
+				stringOption("place", "地名（例: 大阪、さっぽろ）。schedule の場合は「07:00 大阪」や「off」、alert の場合は「大阪」「join」「leave」「off」", false),
+			},
+			handler: b.handleWeather,
+		},
+		{name: "news", description: "話題の記事をお届けしちゃうよ！ 暇な時はこれ！ :newspaper:", feature: config.FeatureNews, handler: b.handleNews},
+		{
+			name:        "gourmet",
+			aliases:     []string{"gurume", "grm"},
+			description: "お料理屋さんを探すよ、「/gurume 新宿 焼肉,個室,食べ放題」みたいに使ってね。カンマは「、」でもOK！ :fork_knife_plate:",
+			usage:       "[地域] [キーワード]",
+			feature:     config.FeatureGourmet,
+			options: []*discordgo.ApplicationCommandOption{
+				stringOption("area", "探す地域（例: 新宿）", false),
+				stringOption("keyword", "キーワード（例: 焼肉,個室）", false),
+			},
+			handler: b.handleGourmet,
+		},
+		{
+			name:        "image",
+			aliases:     []string{"img"},
+			description: "いい写真を見つけてくるよ！ 1日100回までしか検索できないみたい… :art:",
+			usage:       "<検索ワード>",
+			feature:     config.FeatureImage,
+			options:     []*discordgo.ApplicationCommandOption{stringOption("query", "検索ワード", true)},
+			handler:     b.handleImage,
+		},
+		{
+			name:        "dice",
+			description: "サイコロを回すよ。引数があると、それを最大値とするサイコロを回すよ :game_die:",
+			usage:       "[最大値]",
+			options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "max", Description: "サイコロの最大値", MinValue: &minDiceValue},
+			},
+			handler: b.handleDice,
+		},
+		{name: "rank", description: "最近ヒマそうにしてる人を教えてあげるね :kiss_ww:", handler: b.handleRank},
+		{
+			name:        "eng",
+			description: "英語でなんて言うのかがんばって翻訳するよ！ :capital_abcd:",
+			usage:       "<テキスト>",
+			options:     []*discordgo.ApplicationCommandOption{stringOption("text", "翻訳するテキスト", true)},
+			handler:     func(ctx *CommandContext) { b.handleTranslate(ctx, "en") },
+		},
+		{
+			name:        "jpn",
+			aliases:     []string{"jap"},
+			description: "日本語でどう言うのか考えるよ！ :flag_jp:",
+			usage:       "<テキスト>",
+			options:     []*discordgo.ApplicationCommandOption{stringOption("text", "翻訳するテキスト", true)},
+			handler:     func(ctx *CommandContext) { b.handleTranslate(ctx, "ja") },
+		},
+		{
+			name:        "video",
+			aliases:     []string{"youtube"},
+			description: "YouTubeから動画を探してくるよ！ 「/video ゲーム実況」みたいに使ってね :arrow_forward:",
+			usage:       "[検索ワード]",
+			feature:     config.FeatureVideo,
+			options:     []*discordgo.ApplicationCommandOption{stringOption("query", "検索ワード", false)},
+			handler:     b.handleVideo,
+		},
+		{
+			name:        "vtuber",
+			description: "VTuberさんの動画を探してくるよ！ :dancer:",
+			usage:       "[検索ワード]",
+			feature:     config.FeatureVideo,
+			options:     []*discordgo.ApplicationCommandOption{stringOption("query", "検索ワード", false)},
+			handler:     b.handleVTuber,
+		},
+		{
+			name:        "home",
+			description: "よくいる場所を覚えるよ。天気やグルメ検索で場所を省略した時に使うね :house:",
+			usage:       "[set|clear] [地名]",
+			options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "操作",
+					Choices:     stringChoices(homeActions...),
+				},
+				stringOption("place", "よくいる場所（例: 新宿、大阪市北区）", false),
+			},
+			handler: b.handleHome,
+		},
+		{name: "quota", description: "回数に限りのある検索が、今日あと何回できるか教えるよ :bar_chart:", handler: b.handleQuota},
+		{name: "ping", description: "テスト用だよ", handler: b.handlePing},
+		{
+			name:        "config",
+			description: "このサーバーでのわたしの設定を変えるよ（管理者さん専用）:gear:",
+			usage:       "[prefix|disable|enable|weather_city|gourmet_area|language|reset] [値]",
+			options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "key",
+					Description: "変更する項目",
+					Choices:     stringChoices("prefix", "disable", "enable", "weather_city", "gourmet_area", "language", "reset"),
+				},
+				stringOption("value", "設定する値", false),
+			},
+			handler: b.handleConfig,
+		},
+		{
+			name:        "perm",
+			description: "コマンドを使える人やチャンネルを決めるよ（管理者さん専用）:lock:",
+			usage:       "[コマンド名] [require_permission|add_role|remove_role|allow_user|deny_user|remove_user|add_channel|remove_channel|clear] [値]",
+			options: []*discordgo.ApplicationCommandOption{
+				stringOption("command", "ルールを決めるコマンド名", false),
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "変更の内容",
+					Choices:     stringChoices(permActions...),
+				},
+				stringOption("value", "権限名、またはロール・ユーザー・チャンネルのメンション", false),
+			},
+			handler: b.handlePerm,
+		},
+		{
+			name:        "help",
+			description: "これだよ",
+			usage:       "[コマンド名]",
+			options:     []*discordgo.ApplicationCommandOption{stringOption("command", "使い方を知りたいコマンド名", false)},
+			handler:     b.handleHelp,
+		},
+	}
+
+	for _, cmd := range commands {
+		b.commands.Register(cmd)
+	}
+}
+
+// cfg は現在の設定を返す
+func (b *KizunaBot) cfg() *config.Config {
+	return b.config.Load()
+}
+
+// Reload は設定を読み込み直し、ボットとAPIクライアントが使う設定をまとめて差し替える
+// Discordとの接続は維持したまま、APIキーの更新などを反映できる
+func (b *KizunaBot) Reload(configPath string) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+
+	old := b.config.Swap(cfg)
+	b.apiClient.SetConfig(cfg)
+
+	changes := config.Diff(old, cfg)
+	if len(changes) == 0 {
+		slog.Info("設定を読み込み直しました（変更なし）")
+		return nil
+	}
+	slog.Info("設定を読み込み直しました", "changes", changes)
+
+	// ログのレベルはすぐに反映できるが、形式の変更には再起動が必要
+	if err := logging.SetLevel(cfg.Log.Level); err != nil {
+		slog.Error("ログのレベルの変更に失敗しました", "error", err)
+	}
+	if old.Log.Format != cfg.Log.Format {
+		slog.Warn("log.format の変更を反映するにはボットの再起動が必要です")
+	}
+
+	// Botトークン・データベース・監視用のHTTPサーバーのアドレスは起動時にしか使われないため、反映には再起動が必要
+	if old.BotToken != cfg.BotToken {
+		slog.Warn("bot_token の変更を反映するにはボットの再起動が必要です")
+	}
+	if old.DatabasePath != cfg.DatabasePath {
+		slog.Warn("database_path の変更を反映するにはボットの再起動が必要です")
+	}
+	if old.HTTPAddr != cfg.HTTPAddr {
+		slog.Warn("http_addr の変更を反映するにはボットの再起動が必要です（監視用のHTTPサーバーは元のアドレスのままです）",
+			"current", old.HTTPAddr, "new", cfg.HTTPAddr)
+	}
+
+	// アプリケーションコマンドの登録先が変わった場合は登録し直す
+	if old.ApplicationCommandGuildID != cfg.ApplicationCommandGuildID && b.session != nil && b.session.State.User != nil {
+		b.registerApplicationCommands(b.session)
+	}
+
+	b.reportDisabledFeatures()
+	return nil
+}
+
+// reportDisabledFeatures はAPIキーが足りずに使えないコマンドを起動時にログへ出力
+func (b *KizunaBot) reportDisabledFeatures() {
+	disabled := b.cfg().DisabledFeatures()
+	for _, cmd := range b.commands.Commands() {
+		if missing, ok := disabled[cmd.Feature()]; ok {
+			slog.Warn("APIキーが未設定のため使えないコマンドがあります",
+				"commands", formatCommandNames("/", cmd), "missing", strings.Join(missing, ", "))
+		}
+	}
+}
+
+// minDiceValue はサイコロの最大値として指定できる最小の値
+var minDiceValue float64 = 1
+
+// stringOption は文字列型のアプリケーションコマンド引数の定義を作成
+func stringOption(name, description string, required bool) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        name,
+		Description: description,
+		Required:    required,
+	}
+}
+
+// stringChoices は表示名と値が同じ文字列型の選択肢を作成
+func stringChoices(values ...string) []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, value := range values {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: value, Value: value})
+	}
+	return choices
+}
+
 // Start はDiscordサーバーへの接続を開始
 func (b *KizunaBot) Start() error {
+	if err := b.startMonitor(); err != nil {
+		return fmt.Errorf("failed to start monitoring server: %w", err)
+	}
+
 	err := b.session.Open()
 	if err != nil {
 		return fmt.Errorf("failed to open Discord session: %w", err)
 	}
+
+	// 天気予報の定期配信を始める（b.ctxがキャンセルされると止まる）
+	go b.runScheduler()
 	return nil
 }
 
 // Close はDiscordとの接続を安全に切断
+// 新しいイベントの受け付けを止め、実行中の処理が終わるのを設定された時間だけ待ってから切断する
 func (b *KizunaBot) Close() {
-	b.session.Close()
+	// 待ちきれなかった処理は、実行中の外部API呼び出しを中断して打ち切る
+	for _, description := range b.handlers.drain(b.cfg().ShutdownGracePeriod) {
+		slog.Warn("終了処理のため、実行中の処理を打ち切りました", "handler", description)
+	}
+	b.cancel()
+
+	if b.session != nil {
+		b.session.Close()
+	}
+	b.stopMonitor()
+
+	// Discordとの接続を切ってから、状態の保存先を閉じる
+	if err := b.store.Close(); err != nil {
+		slog.Error("データベースのクローズに失敗しました", "error", err)
+	}
 }
 
 // ready はボットがDiscordに正常に接続された時に呼ばれるイベントハンドラー
 func (b *KizunaBot) ready(s *discordgo.Session, event *discordgo.Ready) {
-	log.Printf("ボットが正常に起動しました！ ログイン名: %s", event.User.String())
-	log.Printf("招待URL: https://discord.com/api/oauth2/authorize?client_id=%s&permissions=2048&scope=bot", b.config.BotClientID)
+	b.gateway.ready.Store(true)
+
+	slog.Info("ボットが正常に起動しました！", "user", event.User.String())
+	slog.Info("招待URL: https://discord.com/api/oauth2/authorize?client_id=" + b.cfg().BotClientID + "&permissions=2048&scope=bot+applications.commands")
+
+	// アプリケーションコマンド（Discordのスラッシュコマンド）を登録
+	b.registerApplicationCommands(s)
 }
 
 // messageCreate はDiscordでメッセージが投稿された時に呼ばれるイベントハンドラー
 func (b *KizunaBot) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// 終了処理が始まった後に届いたメッセージは処理しない
+	done, ok := b.handlers.begin(fmt.Sprintf("チャンネル %s のメッセージ「%s」", m.ChannelID, textutil.Truncate(m.Content, 30)))
+	if !ok {
+		return
+	}
+	defer done()
+
+	b.handleMessage(m, s.State.User.ID)
+}
+
+// handleMessage は投稿されたメッセージの種類に応じて、コマンド・メンション・自動応答の処理に振り分ける
+// selfIDにはボット自身のユーザーIDを渡す
+func (b *KizunaBot) handleMessage(m *discordgo.MessageCreate, selfID string) {
 	// ボット自身が投稿したメッセージは無視（無限ループを防ぐため）
-	if m.Author.ID == s.State.User.ID {
+	if m.Author.ID == selfID {
 		return
 	}
 
-	// スラッシュ（/）で始まるコマンドの処理
-	if strings.HasPrefix(m.Content, "/") {
-		b.handleCommand(s, m)
+	// メッセージの処理が終わるか、ボットが終了するまで有効なコンテキスト
+	// このメッセージに関するログには、同じ相関IDと発言した場所・ユーザーが付く
+	ctx, cancel := context.WithCancel(b.ctx)
+	defer cancel()
+	ctx = logging.With(ctx,
+		"correlation_id", logging.NewCorrelationID(),
+		"message_id", m.ID,
+		"guild_id", m.GuildID,
+		"channel_id", m.ChannelID,
+		"user_id", m.Author.ID,
+	)
+
+	// ギルドごとに設定されたプレフィックス（既定はスラッシュ）で始まるコマンドの処理
+	settings := b.guildSettings(m.GuildID)
+	if strings.HasPrefix(m.Content, settings.CommandPrefix()) {
+		b.handleCommand(ctx, m, settings)
 		return
 	}
 
 	// ボットがメンション（@名前）された時の処理
 	for _, user := range m.Mentions {
-		if user.ID == s.State.User.ID {
-			b.handleMention(s, m)
+		if user.ID == selfID {
+			b.handleMention(ctx, m, settings)
 			return
 		}
 	}
 
 	// 特定のキーワードを含むメッセージに対する自動応答
-	b.handlePatternMatching(s, m)
+	b.handlePatternMatching(ctx, m, settings)
 }
 
-// handleCommand はスラッシュコマンド（/で始まるコマンド）を解析して適切な処理関数を呼び出す
-func (b *KizunaBot) handleCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+// handleCommand はプレフィックスで始まるテキストコマンドを解析して適切な処理関数を呼び出す
+func (b *KizunaBot) handleCommand(ctx context.Context, m *discordgo.MessageCreate, settings *GuildSettings) {
 	// メッセージの前後の空白を除去
 	content := strings.TrimSpace(m.Content)
 	// スペースで区切ってコマンドと引数に分離
@@ -110,42 +454,76 @@ func (b *KizunaBot) handleCommand(s *discordgo.Session, m *discordgo.MessageCrea
 		return
 	}
 
-	// コマンド部分（最初の要素）を小文字に変換
-	command := strings.ToLower(parts[0])
-	// 引数部分（2番目以降の要素）を取得
-	args := parts[1:]
-
-	// コマンドに応じて対応する処理関数を呼び出し
-	switch command {
-	case "/ping":
-		b.handlePing(s, m) // 応答速度テスト
-	case "/help":
-		b.handleHelp(s, m) // ヘルプメッセージ表示
-	case "/weather":
-		b.handleWeather(s, m) // 天気予報取得
-	case "/news":
-		b.handleNews(s, m) // ニュース記事取得
-	case "/dice":
-		b.handleDice(s, m, args) // サイコロ機能
-	case "/gourmet", "/gurume", "/grm":
-		b.handleGourmet(s, m, args) // グルメ検索（複数のエイリアス対応）
-	case "/image", "/img":
-		b.handleImage(s, m, args) // 画像検索
-	case "/rank":
-		b.handleRank(s, m) // ユーザーアクティビティランキング
-	case "/eng":
-		b.handleTranslate(s, m, args, "en") // 英語翻訳
-	case "/jpn", "/jap":
-		b.handleTranslate(s, m, args, "ja") // 日本語翻訳
-	case "/video", "/youtube":
-		b.handleVideo(s, m, args) // 動画検索
-	case "/vtuber":
-		b.handleVTuber(s, m, args) // VTuber動画検索
+	// コマンド部分（最初の要素）からプレフィックスを取り除き、登録済みのコマンドを探す
+	name := strings.TrimPrefix(parts[0], settings.CommandPrefix())
+	cmd, ok := b.commands.Find(name)
+	if !ok {
+		return
 	}
+
+	b.executeCommand(cmd, &CommandContext{
+		Context:   ctx,
+		Messenger: b.messenger,
+		Message:   m,
+		ChannelID: m.ChannelID,
+		GuildID:   m.GuildID,
+		Author:    m.Author,
+		Member:    m.Member,
+		Guild:     settings,
+		Name:      strings.ToLower(name),
+		Args:      parts[1:], // 引数部分（2番目以降の要素）
+	})
+}
+
+// executeCommand はテキストコマンド・アプリケーションコマンド共通のミドルウェアを通してコマンドを実行
+func (b *KizunaBot) executeCommand(cmd Command, ctx *CommandContext) {
+	ctx.Command = cmd
+	chain(cmd.Execute, b.commandMiddlewares()...)(ctx)
+}
+
+// cooldownWait は設定された制限に従ってコマンドの実行回数を数え、制限を超えている場合は待ち時間を返す
+// ユーザーごと・チャンネルごとの制限のうち、待ち時間の長い方を返す（実行できる場合は0）
+// どちらかの制限で断った場合は、どちらの回数も数えない
+func (b *KizunaBot) cooldownWait(ctx *CommandContext, cmd Command) time.Duration {
+	cooldown, ok := b.cfg().Cooldowns[cmd.Name()]
+	if !ok {
+		return 0
+	}
+
+	// 両方の制限を満たす時だけ、両方から1回分ずつ数える
+	_, wait := b.limiter.AllowAll(
+		ratelimit.Limit{Key: "user:" + ctx.Author.ID + ":" + cmd.Name(), Rule: cooldown.User},
+		ratelimit.Limit{Key: "channel:" + ctx.ChannelID + ":" + cmd.Name(), Rule: cooldown.Channel},
+	)
+	return wait
+}
+
+// formatWait は待ち時間を「1分30秒」のような表示用の文字列にする（秒未満は切り上げ）
+func formatWait(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 60 {
+		return fmt.Sprintf("%d秒", seconds)
+	}
+	if seconds%60 == 0 {
+		return fmt.Sprintf("%d分", seconds/60)
+	}
+	return fmt.Sprintf("%d分%d秒", seconds/60, seconds%60)
+}
+
+// featureUnavailableMessage は設定不足で使えない機能を呼ばれた時の返答を作る
+func featureUnavailableMessage(name string) string {
+	return fmt.Sprintf("ごめんね、%s は今使えないみたい……管理者さんに設定をお願いしてね", name)
 }
 
 // handleMention はボットがメンション（@で呼び出し）された時の処理
-func (b *KizunaBot) handleMention(s *discordgo.Session, m *discordgo.MessageCreate) {
+func (b *KizunaBot) handleMention(ctx context.Context, m *discordgo.MessageCreate, settings *GuildSettings) {
+	chain(b.replyToMention, b.replyMiddlewares()...)(b.messageContext(ctx, m, settings))
+}
+
+// replyToMention はメンションの内容に応じた会話の返答を送信する
+func (b *KizunaBot) replyToMention(ctx *CommandContext) {
+	m := ctx.Message
+
 	// メッセージからメンション部分を除去して実際の内容を取得
 	content := m.Content
 	for _, user := range m.Mentions {
@@ -156,50 +534,76 @@ func (b *KizunaBot) handleMention(s *discordgo.Session, m *discordgo.MessageCrea
 	content = strings.TrimSpace(content)
 
 	// メンション内容に応じた会話応答を生成
-	reply := b.getMunouMessage(content, s, m)
+	reply := b.getMunouMessage(ctx.Context, content, m)
 	if reply != "" {
-		s.ChannelMessageSend(m.ChannelID, reply)
+		ctx.Reply(reply)
+	}
+}
+
+// messageContext はコマンド以外のメッセージへの応答に使うCommandContextを作る
+func (b *KizunaBot) messageContext(ctx context.Context, m *discordgo.MessageCreate, settings *GuildSettings) *CommandContext {
+	return &CommandContext{
+		Context:   ctx,
+		Messenger: b.messenger,
+		Message:   m,
+		ChannelID: m.ChannelID,
+		GuildID:   m.GuildID,
+		Author:    m.Author,
+		Member:    m.Member,
+		Guild:     settings,
 	}
 }
 
 // handlePatternMatching processes message patterns
-func (b *KizunaBot) handlePatternMatching(s *discordgo.Session, m *discordgo.MessageCreate) {
+func (b *KizunaBot) handlePatternMatching(ctx context.Context, m *discordgo.MessageCreate, settings *GuildSettings) {
 	content := strings.ToLower(m.Content)
 
 	// Only respond to specific patterns when not mentioned
 	if strings.Contains(content, "天気は？") && len(m.Mentions) == 0 {
-		b.handleWeather(s, m)
+		chain(b.handleWeatherQuestion, b.replyMiddlewares()...)(b.messageContext(ctx, m, settings))
 	}
 }
 
 // handlePing responds to ping command
-func (b *KizunaBot) handlePing(s *discordgo.Session, m *discordgo.MessageCreate) {
+func (b *KizunaBot) handlePing(ctx *CommandContext) {
 	start := time.Now()
-	msg, err := s.ChannelMessageSend(m.ChannelID, "Pong！")
+	msg, err := ctx.Reply("Pong！")
 	if err != nil {
-		log.Printf("Error sending ping message: %v", err)
+		ctx.Logger().Error("pingの応答の送信に失敗しました", "error", err)
 		return
 	}
 
 	duration := time.Since(start)
 	editedContent := fmt.Sprintf("Pong！ 応答までに %.3f 秒かかったよ！", duration.Seconds())
-	s.ChannelMessageEdit(m.ChannelID, msg.ID, editedContent)
-}
-
-// handleHelp shows help message
-func (b *KizunaBot) handleHelp(s *discordgo.Session, m *discordgo.MessageCreate) {
-	helpMessage := `/weather : 天気を教えるよ〜 :white_sun_small_cloud:
-/news : 話題の記事をお届けしちゃうよ！ 暇な時はこれ！ :newspaper:
-/gurume, /grm : お料理屋さんを探すよ、「/gurume 新宿 焼肉,個室,食べ放題」みたいに使ってね。カンマは「、」でもOK！ :fork_knife_plate:
-/image, /img : いい写真を見つけてくるよ！ 1日100回までしか検索できないみたい… :art:
-/dice : サイコロを回すよ。引数があると、それを最大値とするサイコロを回すよ :game_die:
-/rank : 最近ヒマそうにしてる人を教えてあげるね :kiss_ww:
-/eng : 英語でなんて言うのかがんばって翻訳するよ！ :capital_abcd:
-/jpn : 日本語でどう言うのか考えるよ！ :flag_jp:
-/video, /youtube : YouTubeから動画を探してくるよ！ 「/video ゲーム実況」みたいに使ってね :arrow_forward:
-/vtuber : VTuberさんの動画を探してくるよ！ :dancer:
-/ping : テスト用だよ
-/help : これだよ`
-
-	s.ChannelMessageSend(m.ChannelID, helpMessage)
+	ctx.Edit(msg, editedContent)
+}
+
+// handleHelp はレジストリに登録されたコマンドからヘルプメッセージを生成して表示
+// 引数でコマンド名が指定された場合は、そのコマンドの使い方を表示する
+func (b *KizunaBot) handleHelp(ctx *CommandContext) {
+	prefix := ctx.Guild.CommandPrefix()
+
+	if len(ctx.Args) > 0 {
+		name := strings.TrimPrefix(ctx.Args[0], prefix)
+		cmd, ok := b.commands.Find(name)
+		if !ok {
+			ctx.Reply(fmt.Sprintf("「%s」というコマンドは知らないなあ……", ctx.Args[0]))
+			return
+		}
+
+		message := fmt.Sprintf("%s : %s\n", formatCommandNames(prefix, cmd), cmd.Description())
+		message += "使い方: " + strings.TrimSpace(prefix+cmd.Name()+" "+cmd.Usage())
+		ctx.Reply(message)
+		return
+	}
+
+	var lines []string
+	for _, cmd := range b.commands.Commands() {
+		// このギルドでお休み中のコマンドは表示しない
+		if ctx.Guild.IsDisabled(cmd.Name()) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s : %s", formatCommandNames(prefix, cmd), cmd.Description()))
+	}
+	ctx.Reply(strings.Join(lines, "\n"))
 }
@@ -0,0 +1,265 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"kizuna_bot_go/internal/api"
+	"kizuna_bot_go/internal/config"
+	"kizuna_bot_go/internal/messenger"
+)
+
+const (
+	testGuildID   = "guild"
+	testChannelID = "channel"
+)
+
+var (
+	testUser  = &discordgo.User{ID: "user", Username: "user"}
+	testAdmin = &discordgo.User{ID: "admin", Username: "admin"}
+)
+
+// newTestBot はFakeに送信し、一時ディレクトリのデータベースを使うボットを作成
+// modifyで設定を書き換えられる（外部APIの接続先をテスト用のサーバーにするなど）
+func newTestBot(t *testing.T, modify ...func(cfg *config.Config)) (*KizunaBot, *messenger.Fake) {
+	t.Helper()
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	cfg.DatabasePath = filepath.Join(t.TempDir(), "kizuna.db")
+	for _, m := range modify {
+		m(cfg)
+	}
+
+	fake := messenger.NewFake()
+	fake.SetPermissions(testAdmin.ID, discordgo.PermissionAll)
+	b, err := newKizunaBot(cfg, fake)
+	if err != nil {
+		t.Fatalf("newKizunaBot: %v", err)
+	}
+	t.Cleanup(b.Close)
+	return b, fake
+}
+
+// post はユーザーがチャンネルに投稿したものとしてメッセージを処理し、その間にボットが送信したメッセージを返す
+func post(b *KizunaBot, fake *messenger.Fake, author *discordgo.User, content string) []*discordgo.Message {
+	before := len(fake.Sent())
+	msg := fake.AddMessage(&discordgo.Message{
+		ChannelID: testChannelID,
+		GuildID:   testGuildID,
+		Content:   content,
+		Author:    author,
+	})
+	b.handleMessage(&discordgo.MessageCreate{Message: msg}, fake.Self().ID)
+	return fake.Sent()[before:]
+}
+
+func TestHandleHelp(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    []string // 管理者として先に送るメッセージ
+		content  string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "全てのコマンドを表示",
+			content:  "/help",
+			contains: []string{"/ping : テスト用だよ", "/weather", "/help"},
+		},
+		{
+			name:     "コマンドの使い方を表示",
+			content:  "/help weather",
+			contains: []string{"/weather"},
+		},
+		{
+			name:     "知らないコマンド",
+			content:  "/help nothing",
+			contains: []string{"「nothing」というコマンドは知らないなあ"},
+		},
+		{
+			name:     "お休み中のコマンドは表示しない",
+			setup:    []string{"/config disable ping"},
+			content:  "/help",
+			contains: []string{"/weather"},
+			excludes: []string{"/ping"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newTestBot(t)
+			for _, content := range tt.setup {
+				post(b, fake, testAdmin, content)
+			}
+
+			sent := post(b, fake, testUser, tt.content)
+			if len(sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(sent))
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(sent[0].Content, s) {
+					t.Errorf("reply does not contain %q:\n%s", s, sent[0].Content)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(sent[0].Content, s) {
+					t.Errorf("reply contains %q:\n%s", s, sent[0].Content)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlePingEditsReply(t *testing.T) {
+	b, fake := newTestBot(t)
+
+	sent := post(b, fake, testUser, "/ping")
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	// 最初は「Pong！」と送り、応答時間を計ってから同じメッセージを書き換える
+	if got := sent[0].Content; !strings.HasPrefix(got, "Pong！ 応答までに ") || !strings.HasSuffix(got, "秒かかったよ！") {
+		t.Errorf("reply = %q, want edited with the response time", got)
+	}
+}
+
+func TestDisabledCommand(t *testing.T) {
+	b, fake := newTestBot(t)
+
+	sent := post(b, fake, testAdmin, "/config disable ping")
+	if len(sent) != 1 || !strings.Contains(sent[0].Content, "ping") {
+		t.Fatalf("unexpected reply to /config disable: %v", sent)
+	}
+
+	sent = post(b, fake, testUser, "/ping")
+	if len(sent) != 1 || sent[0].Content != "このサーバーでは /ping はお休み中だよ" {
+		t.Fatalf("reply to disabled /ping = %v", sent)
+	}
+
+	post(b, fake, testAdmin, "/config enable ping")
+	sent = post(b, fake, testUser, "/ping")
+	if len(sent) != 1 || !strings.HasPrefix(sent[0].Content, "Pong！") {
+		t.Fatalf("reply to re-enabled /ping = %v", sent)
+	}
+}
+
+func TestDisableIsAdminOnly(t *testing.T) {
+	b, fake := newTestBot(t)
+
+	post(b, fake, testUser, "/config disable ping")
+	sent := post(b, fake, testUser, "/ping")
+	if len(sent) != 1 || !strings.HasPrefix(sent[0].Content, "Pong！") {
+		t.Fatalf("/ping was disabled by a non-admin: %v", sent)
+	}
+}
+
+func TestCommandsMatchConfig(t *testing.T) {
+	b, _ := newTestBot(t)
+
+	// cooldownsに書けるコマンド名は、登録されているコマンドと同じでなければならない
+	var names []string
+	for _, cmd := range b.commands.Commands() {
+		names = append(names, cmd.Name())
+	}
+	if !slices.Equal(slices.Sorted(slices.Values(names)), slices.Sorted(slices.Values(config.Commands))) {
+		t.Errorf("registered commands = %v, config.Commands = %v", names, config.Commands)
+	}
+}
+
+func TestReloadWarnsAboutRestartOnlyFields(t *testing.T) {
+	b, _ := newTestBot(t)
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := fmt.Sprintf("http_addr: \"127.0.0.1:9199\"\ndatabase_path: %q\n", b.cfg().DatabasePath)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Reload(path); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if !strings.Contains(logs.String(), "http_addr の変更を反映するにはボットの再起動が必要です") {
+		t.Errorf("no restart warning for http_addr:\n%s", logs.String())
+	}
+	if strings.Contains(logs.String(), "database_path") {
+		t.Errorf("unexpected warning for an unchanged database_path:\n%s", logs.String())
+	}
+}
+
+// forecastServer は天気予報APIの代わりに、設定された予報を返すテスト用のサーバー
+type forecastServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	forecasts []api.WeatherForecast
+	onRequest func() // リクエストを受けるたびに呼ばれる（nilでもよい）
+}
+
+// newForecastServer はforecastServerを起動し、ボットの天気予報の接続先にする設定を返す
+func newForecastServer(t *testing.T) (*forecastServer, func(cfg *config.Config)) {
+	t.Helper()
+
+	s := &forecastServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		onRequest := s.onRequest
+		response := map[string]any{
+			"title":     "東京都 東京 の天気",
+			"forecasts": s.forecasts,
+			"location":  map[string]string{"prefecture": "東京都", "city": "東京"},
+		}
+		s.mu.Unlock()
+
+		if onRequest != nil {
+			onRequest()
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(s.Close)
+
+	return s, func(cfg *config.Config) {
+		cfg.LivedoorWeatherAPIHost = s.URL
+		cfg.Cache.TTLs = nil // 予報を変えた時にすぐ反映されるよう、キャッシュしない
+	}
+}
+
+// setOnRequest はリクエストを受けるたびに呼ぶ関数を設定する（nilで解除）
+func (s *forecastServer) setOnRequest(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onRequest = fn
+}
+
+// setForecasts は返す予報を差し替える
+func (s *forecastServer) setForecasts(forecasts ...api.WeatherForecast) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forecasts = forecasts
+}
+
+// forecast はテスト用の1日分の予報を作成
+func forecast(date, label, telop string, chances ...string) api.WeatherForecast {
+	f := api.WeatherForecast{Date: date, DateLabel: label, Telop: telop}
+	periods := []*string{&f.ChanceOfRain.T00To06, &f.ChanceOfRain.T06To12, &f.ChanceOfRain.T12To18, &f.ChanceOfRain.T18To24}
+	for i, chance := range chances {
+		*periods[i] = chance
+	}
+	return f
+}
@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"kizuna_bot_go/internal/config"
+	"kizuna_bot_go/internal/logging"
+	"kizuna_bot_go/internal/messenger"
+)
+
+// Command はボットが受け付けるコマンド1つ分の定義を表すインターフェース
+type Command interface {
+	Name() string                // コマンド名（プレフィックスなし、例: "weather"）
+	Aliases() []string           // 別名（プレフィックスなし、例: "img"）
+	Description() string         // ヘルプに表示する説明文
+	Usage() string               // 引数の書き方（例: "<地域> [キーワード]"）
+	Execute(ctx *CommandContext) // コマンドの処理本体
+
+	// Feature はコマンドが依存する機能を返す（設定に依存しない場合は空）
+	// 必要なAPIキーが設定されていない場合、コマンドは実行されない
+	Feature() config.Feature
+
+	// Options はアプリケーションコマンドとして登録する際の引数定義を返す
+	// 定義順がテキストコマンドでの引数の順番に対応する
+	Options() []*discordgo.ApplicationCommandOption
+}
+
+// CommandContext はコマンド実行時に処理関数へ渡される情報をまとめた構造体
+// テキストコマンドとアプリケーションコマンドのどちらから呼ばれても同じように扱える
+type CommandContext struct {
+	Context     context.Context              // コマンドの処理に使うコンテキスト（ボットの終了時にキャンセルされる）
+	Messenger   messenger.Messenger          // テキストコマンドの応答に使う送信先
+	Session     *discordgo.Session           // Discord APIとの通信セッション（アプリケーションコマンドの場合のみ）
+	Message     *discordgo.MessageCreate     // コマンドを含むメッセージ（テキストコマンドの場合のみ）
+	Interaction *discordgo.InteractionCreate // アプリケーションコマンドの場合のみ
+	ChannelID   string                       // コマンドが実行されたチャンネルのID
+	GuildID     string                       // コマンドが実行されたギルドのID（DMの場合は空）
+	Author      *discordgo.User              // コマンドを実行したユーザー
+	Member      *discordgo.Member            // コマンドを実行したユーザーのギルドでの情報（DMの場合はnil）
+	Guild       *GuildSettings               // コマンドが実行されたギルドの設定
+	Name        string                       // 実際に入力されたコマンド名（別名の場合もある）
+	Args        []string                     // コマンド名以降の引数
+	Command     Command                      // 実行するコマンド（メンションなどへの自動応答の場合はnil）
+
+	responseID string // アプリケーションコマンドへの最初の応答メッセージのID
+	result     string // 処理結果（resultExecuted など、ログやメトリクスに使う）
+}
+
+// Reply はコマンドが実行されたチャンネルにメッセージを送信
+// アプリケーションコマンドの場合は、保留中の応答を書き換えるか追加のメッセージとして送信する
+func (c *CommandContext) Reply(content string) (*discordgo.Message, error) {
+	if c.Interaction == nil {
+		return c.Messenger.Send(c.ChannelID, content)
+	}
+
+	if c.responseID == "" {
+		msg, err := c.Session.InteractionResponseEdit(c.Interaction.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.responseID = msg.ID
+		return msg, nil
+	}
+
+	return c.Session.FollowupMessageCreate(c.Interaction.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+	})
+}
+
+// Permissions はコマンドを実行したユーザーがこのチャンネルで持つ権限を返す
+func (c *CommandContext) Permissions() (int64, error) {
+	// アプリケーションコマンドの場合は、Discordが計算済みの権限を送ってくれる
+	if c.Interaction != nil && c.Interaction.Member != nil {
+		return c.Interaction.Member.Permissions, nil
+	}
+	return c.Messenger.Permissions(c.ChannelID, c.Author.ID)
+}
+
+// Logger はこのコマンドの処理に関するログを出力するロガーを返す
+// 相関IDやギルド・チャンネル・ユーザー・コマンド名がログに付く
+func (c *CommandContext) Logger() *slog.Logger {
+	if c.Context == nil {
+		return slog.Default()
+	}
+	return logging.FromContext(c.Context)
+}
+
+// IsAdmin はコマンドを実行したユーザーがサーバーの管理者（管理者またはサーバー管理の権限を持つ）かどうかを返す
+func (c *CommandContext) IsAdmin() bool {
+	if c.GuildID == "" {
+		return false
+	}
+
+	perms, err := c.Permissions()
+	if err != nil {
+		c.Logger().Error("権限の取得に失敗しました", "error", err)
+		return false
+	}
+	return isAdminPermissions(perms)
+}
+
+// Edit はReplyで送信したメッセージの内容を書き換える
+func (c *CommandContext) Edit(msg *discordgo.Message, content string) (*discordgo.Message, error) {
+	if c.Interaction == nil {
+		return c.Messenger.Edit(msg.ChannelID, msg.ID, content)
+	}
+
+	edit := &discordgo.WebhookEdit{Content: &content}
+	if msg.ID == c.responseID {
+		return c.Session.InteractionResponseEdit(c.Interaction.Interaction, edit)
+	}
+	return c.Session.FollowupMessageEdit(c.Interaction.Interaction, msg.ID, edit)
+}
+
+// simpleCommand は処理関数と説明文を組み合わせただけのCommand実装
+type simpleCommand struct {
+	name        string
+	aliases     []string
+	description string
+	usage       string
+	feature     config.Feature
+	options     []*discordgo.ApplicationCommandOption
+	handler     func(ctx *CommandContext)
+}
+
+func (c *simpleCommand) Name() string                { return c.name }
+func (c *simpleCommand) Aliases() []string           { return c.aliases }
+func (c *simpleCommand) Description() string         { return c.description }
+func (c *simpleCommand) Usage() string               { return c.usage }
+func (c *simpleCommand) Execute(ctx *CommandContext) { c.handler(ctx) }
+func (c *simpleCommand) Feature() config.Feature     { return c.feature }
+func (c *simpleCommand) Options() []*discordgo.ApplicationCommandOption {
+	return c.options
+}
+
+// commandRegistry は登録されたコマンドを名前・別名から引けるように管理する
+type commandRegistry struct {
+	commands []Command          // 登録順に並んだコマンド一覧（ヘルプの表示順になる）
+	lookup   map[string]Command // 名前・別名（小文字）からコマンドへの対応表
+}
+
+// newCommandRegistry は空のコマンドレジストリを作成
+func newCommandRegistry() *commandRegistry {
+	return &commandRegistry{
+		lookup: make(map[string]Command),
+	}
+}
+
+// Register はコマンドを登録する
+// 名前や別名が既存のコマンドと重複している場合は設定ミスなのでpanicする
+func (r *commandRegistry) Register(cmd Command) {
+	names := append([]string{cmd.Name()}, cmd.Aliases()...)
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if _, exists := r.lookup[key]; exists {
+			panic(fmt.Sprintf("command %q is already registered", name))
+		}
+		r.lookup[key] = cmd
+	}
+	r.commands = append(r.commands, cmd)
+}
+
+// Find は名前または別名からコマンドを探す
+func (r *commandRegistry) Find(name string) (Command, bool) {
+	cmd, ok := r.lookup[strings.ToLower(name)]
+	return cmd, ok
+}
+
+// Commands は登録順にコマンド一覧を返す
+func (r *commandRegistry) Commands() []Command {
+	return r.commands
+}
+
+// formatCommandNames は「/gourmet, /gurume, /grm」のように名前と別名を並べた文字列を作る
+func formatCommandNames(prefix string, cmd Command) string {
+	names := []string{prefix + cmd.Name()}
+	for _, alias := range cmd.Aliases() {
+		names = append(names, prefix+alias)
+	}
+	return strings.Join(names, ", ")
+}
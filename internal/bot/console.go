@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"kizuna_bot_go/internal/config"
+	"kizuna_bot_go/internal/messenger"
+)
+
+const (
+	consoleChannelID     = "console" // コンソールモードで使う仮想チャンネルのID
+	consoleGuildID       = "console" // コンソールモードで使う仮想ギルドのID（/config の保存先）
+	consoleMentionPrefix = "@kizuna" // 行頭に付けるとボットへのメンションとして扱う
+	consolePrompt        = "you> "   // 入力待ちの時に表示するプロンプト
+)
+
+// consoleUser はコンソールから入力したユーザーとして扱う仮想ユーザー
+var consoleUser = &discordgo.User{ID: "console-user", Username: "you"}
+
+// RunConsole はDiscordに接続せず、inから読み込んだ行をメッセージとして処理し、ボットの発言をoutに書き出す
+// 「@kizuna こんにちは」のように行頭に@kizunaを付けるとメンションとして扱う
+func RunConsole(cfg *config.Config, in io.Reader, out io.Writer) error {
+	console := messenger.NewConsole(out)
+	bot, err := newKizunaBot(cfg, console)
+	if err != nil {
+		return err
+	}
+	defer bot.Close()
+	self := console.Self()
+
+	// コンソールの利用者はボットを動かしている本人なので、/config などの管理者向けコマンドも使えるようにする
+	console.SetPermissions(consoleUser.ID, discordgo.PermissionAll)
+
+	fmt.Fprintf(out, "コンソールモードで起動しました。「%s こんにちは」のように話しかけてね（終了は CTRL-D）\n", consoleMentionPrefix)
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, consolePrompt)
+		if !scanner.Scan() {
+			break
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		msg := &discordgo.Message{
+			ChannelID: consoleChannelID,
+			GuildID:   consoleGuildID,
+			Content:   line,
+			Author:    consoleUser,
+		}
+
+		// 行頭の@kizunaをDiscordのメンション形式に置き換える
+		if strings.HasPrefix(line, consoleMentionPrefix) {
+			msg.Content = fmt.Sprintf("<@%s>%s", self.ID, strings.TrimPrefix(line, consoleMentionPrefix))
+			msg.Mentions = []*discordgo.User{self}
+		}
+
+		// /rank で集計できるように、入力した内容も履歴に残しておく
+		console.AddMessage(msg)
+		bot.handleMessage(&discordgo.MessageCreate{Message: msg}, self.ID)
+	}
+	fmt.Fprintln(out)
+
+	return scanner.Err()
+}
@@ -0,0 +1,237 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"kizuna_bot_go/internal/store"
+)
+
+const (
+	defaultPrefix   = "/"  // コマンドのプレフィックスの既定値
+	defaultLanguage = "ja" // 検索結果の言語の既定値
+)
+
+// languagePattern は言語設定として受け付ける形式（「ja」「en」のような2文字の言語コード）
+var languagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// undisableableCommands は設定を元に戻せなくなるため無効化できないコマンド
+var undisableableCommands = []string{"config"}
+
+// GuildSettings はギルド（サーバー）ごとに変更できる設定
+// 未設定の項目はゼロ値のまま保存され、使う時に既定値に置き換えられる
+type GuildSettings struct {
+	Prefix           string   `json:"prefix,omitempty"`            // テキストコマンドのプレフィックス
+	DisabledCommands []string `json:"disabled_commands,omitempty"` // 使えないようにしたコマンド名
+	WeatherCityID    int      `json:"weather_city_id,omitempty"`   // 天気予報で使う都市ID
+	GourmetArea      string   `json:"gourmet_area,omitempty"`      // グルメ検索で地域を省略した時の地域
+	Language         string   `json:"language,omitempty"`          // 画像・動画検索の言語
+
+	// コマンド名ごとの利用ルール（/perm で変更する）
+	CommandRules map[string]*CommandRule `json:"command_rules,omitempty"`
+}
+
+// CommandPrefix はテキストコマンドのプレフィックスを返す
+func (g *GuildSettings) CommandPrefix() string {
+	if g.Prefix == "" {
+		return defaultPrefix
+	}
+	return g.Prefix
+}
+
+// Lang は画像・動画検索で使う言語コードを返す
+func (g *GuildSettings) Lang() string {
+	if g.Language == "" {
+		return defaultLanguage
+	}
+	return g.Language
+}
+
+// IsDisabled は指定されたコマンドがこのギルドで無効にされているかどうかを返す
+func (g *GuildSettings) IsDisabled(name string) bool {
+	return slices.Contains(g.DisabledCommands, name)
+}
+
+// weatherCityID はギルドの設定で天気予報に使う都市IDを返す（未設定なら設定ファイルの既定値）
+func (b *KizunaBot) weatherCityID(settings *GuildSettings) int {
+	if settings.WeatherCityID > 0 {
+		return settings.WeatherCityID
+	}
+	return b.cfg().TokyoCityID
+}
+
+// guildSettings はギルドの設定を読み込む
+// DMの場合や読み込みに失敗した場合は既定の設定を返す
+func (b *KizunaBot) guildSettings(guildID string) *GuildSettings {
+	settings := &GuildSettings{}
+	if guildID == "" {
+		return settings
+	}
+
+	if _, err := b.store.Get(store.BucketGuildSettings, guildID, settings); err != nil {
+		slog.Error("ギルド設定の読み込みに失敗しました（既定の設定を使います）", "guild_id", guildID, "error", err)
+		return &GuildSettings{}
+	}
+	return settings
+}
+
+// loadGuildSettings は変更して保存するためにギルドの設定を読み込み直す（呼び出し側でguildSettingsMuを取得しておくこと）
+// guildSettingsと違い、読み込みに失敗した場合は既定の設定で上書きしないようエラーを返す
+func (b *KizunaBot) loadGuildSettings(guildID string) (*GuildSettings, error) {
+	settings := &GuildSettings{}
+	if _, err := b.store.Get(store.BucketGuildSettings, guildID, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// handleConfig はギルドの設定を表示・変更する（管理者専用）
+func (b *KizunaBot) handleConfig(ctx *CommandContext) {
+	if ctx.GuildID == "" {
+		ctx.Reply(ctx.Guild.CommandPrefix() + "config はサーバーの中で使ってね！")
+		return
+	}
+	if !ctx.IsAdmin() {
+		ctx.Reply(fmt.Sprintf("ごめんね、%sconfig はサーバーの管理者さんしか使えないんだ", ctx.Guild.CommandPrefix()))
+		return
+	}
+
+	// 引数がなければ現在の設定を表示
+	if len(ctx.Args) == 0 {
+		ctx.Reply(formatGuildSettings(ctx.Guild))
+		return
+	}
+
+	// メッセージを受け取った後に他の変更が保存されていても上書きしないよう、ロックを取得してから読み込み直す
+	b.guildSettingsMu.Lock()
+	defer b.guildSettingsMu.Unlock()
+
+	settings, err := b.loadGuildSettings(ctx.GuildID)
+	if err != nil {
+		ctx.Logger().Error("ギルド設定の読み込みに失敗しました", "error", err)
+		ctx.Reply("設定の読み込みに失敗しました。しばらく時間をおいてからお試しください。")
+		return
+	}
+	key := strings.ToLower(ctx.Args[0])
+	value := strings.TrimSpace(strings.Join(ctx.Args[1:], " "))
+
+	var message string
+	switch key {
+	case "prefix":
+		if value == "" || strings.ContainsAny(value, " \t\n") {
+			ctx.Reply(fmt.Sprintf("プレフィックスは空白を含まない文字で指定してね！ 例: %sconfig prefix !", settings.CommandPrefix()))
+			return
+		}
+		settings.Prefix = value
+		message = fmt.Sprintf("コマンドのプレフィックスを「%s」にしたよ！ 例: %shelp", value, value)
+	case "disable", "enable":
+		// プレフィックスを変更した後でも「/dice」のように書けるようにスラッシュも取り除く
+		cmd, ok := b.commands.Find(strings.TrimPrefix(strings.TrimPrefix(value, settings.CommandPrefix()), "/"))
+		if !ok {
+			ctx.Reply(fmt.Sprintf("「%s」というコマンドは知らないなあ……", value))
+			return
+		}
+		name := cmd.Name()
+		if key == "disable" {
+			if slices.Contains(undisableableCommands, name) {
+				ctx.Reply(fmt.Sprintf("%s%s はお休みできないよ！", settings.CommandPrefix(), name))
+				return
+			}
+			if !settings.IsDisabled(name) {
+				settings.DisabledCommands = append(settings.DisabledCommands, name)
+			}
+			message = fmt.Sprintf("%s%s をお休みにしたよ", settings.CommandPrefix(), name)
+		} else {
+			settings.DisabledCommands = removeValue(settings.DisabledCommands, name)
+			message = fmt.Sprintf("%s%s を使えるようにしたよ！", settings.CommandPrefix(), name)
+		}
+	case "weather_city":
+		// 都市IDの数字の代わりに「大阪」や「東京都新宿区」のような地名でも、/weather と同じように指定できる
+		cityID, err := strconv.Atoi(value)
+		if err != nil {
+			city, suggestions, ok := findCity(value)
+			if !ok {
+				ctx.Reply(cityNotFoundMessage(settings.CommandPrefix(), value, suggestions))
+				return
+			}
+			cityID = city.ID
+		}
+		if cityID <= 0 {
+			prefix := settings.CommandPrefix()
+			ctx.Reply(fmt.Sprintf("都市IDか地名で指定してね！ 例: %sconfig weather_city 270000 や %sconfig weather_city 大阪", prefix, prefix))
+			return
+		}
+		settings.WeatherCityID = cityID
+		message = fmt.Sprintf("天気予報の地域を %s にしたよ！", cityLabel(cityID))
+	case "gourmet_area":
+		settings.GourmetArea = value
+		if value == "" {
+			message = "グルメ検索の地域を未設定に戻したよ"
+		} else {
+			message = fmt.Sprintf("グルメ検索の地域を「%s」にしたよ！", value)
+		}
+	case "language":
+		if !languagePattern.MatchString(value) {
+			ctx.Reply("言語は「ja」や「en」のような2文字のコードで指定してね！")
+			return
+		}
+		settings.Language = value
+		message = fmt.Sprintf("検索の言語を「%s」にしたよ！", value)
+	case "reset":
+		settings = &GuildSettings{}
+		message = "このサーバーの設定を最初の状態に戻したよ！"
+	default:
+		ctx.Reply("設定できる項目は prefix, disable, enable, weather_city, gourmet_area, language, reset だよ！")
+		return
+	}
+
+	if err := b.store.Put(store.BucketGuildSettings, ctx.GuildID, settings); err != nil {
+		ctx.Logger().Error("ギルド設定の保存に失敗しました", "error", err)
+		ctx.Reply("設定の保存に失敗しました。しばらく時間をおいてからお試しください。")
+		return
+	}
+	ctx.Guild = settings
+	ctx.Reply(message)
+}
+
+// formatGuildSettings はギルドの設定を表示用の文字列にする
+func formatGuildSettings(settings *GuildSettings) string {
+	prefix := settings.CommandPrefix()
+
+	disabled := "なし"
+	if len(settings.DisabledCommands) > 0 {
+		var names []string
+		for _, name := range settings.DisabledCommands {
+			names = append(names, prefix+name)
+		}
+		disabled = strings.Join(names, ", ")
+	}
+
+	weatherCity := "未設定（東京）"
+	if settings.WeatherCityID > 0 {
+		weatherCity = cityLabel(settings.WeatherCityID)
+	}
+
+	gourmetArea := "未設定"
+	if settings.GourmetArea != "" {
+		gourmetArea = settings.GourmetArea
+	}
+
+	message := "このサーバーの設定だよ！\n"
+	message += fmt.Sprintf("prefix（プレフィックス）: %s\n", prefix)
+	message += fmt.Sprintf("disable（お休み中のコマンド）: %s\n", disabled)
+	message += fmt.Sprintf("weather_city（天気予報の地域）: %s\n", weatherCity)
+	message += fmt.Sprintf("gourmet_area（グルメ検索の地域）: %s\n", gourmetArea)
+	message += fmt.Sprintf("language（検索の言語）: %s", settings.Lang())
+	return message
+}
+
+// isAdminPermissions は権限の中にサーバーの管理権限が含まれているかどうかを返す
+func isAdminPermissions(perms int64) bool {
+	return perms&discordgo.PermissionAdministrator != 0 || perms&discordgo.PermissionManageGuild != 0
+}
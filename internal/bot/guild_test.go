@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"kizuna_bot_go/internal/messenger"
+)
+
+// staleCommand はguildの設定を読み込んだ時点で受け取った、管理者のコマンドのコンテキストを作成
+// （メッセージを受け取ってから処理するまでの間に、他のコマンドで設定が変わった場合を再現する）
+func staleCommand(fake *messenger.Fake, guild *GuildSettings, args ...string) *CommandContext {
+	return &CommandContext{
+		Messenger: fake,
+		ChannelID: testChannelID,
+		GuildID:   testGuildID,
+		Author:    testAdmin,
+		Guild:     guild,
+		Args:      args,
+	}
+}
+
+func TestHandleConfigKeepsConcurrentChanges(t *testing.T) {
+	b, fake := newTestBot(t)
+
+	// languageを変更するメッセージを受け取った後、処理する前にpingがお休みになった
+	stale := b.guildSettings(testGuildID)
+	post(b, fake, testAdmin, "/config disable ping")
+	b.handleConfig(staleCommand(fake, stale, "language", "en"))
+
+	settings := b.guildSettings(testGuildID)
+	if settings.Language != "en" {
+		t.Errorf("Language = %q, want en", settings.Language)
+	}
+	if !slices.Contains(settings.DisabledCommands, "ping") {
+		t.Errorf("DisabledCommands = %v, lost the concurrent disable of ping", settings.DisabledCommands)
+	}
+}
+
+func TestHandleConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		messages  []string
+		wantReply string
+		check     func(t *testing.T, settings *GuildSettings)
+	}{
+		{
+			name:      "プレフィックス",
+			messages:  []string{"/config prefix !"},
+			wantReply: "コマンドのプレフィックスを「!」にしたよ！",
+			check: func(t *testing.T, settings *GuildSettings) {
+				if settings.Prefix != "!" {
+					t.Errorf("Prefix = %q, want !", settings.Prefix)
+				}
+			},
+		},
+		{
+			name:      "地名で天気予報の地域を指定",
+			messages:  []string{"/config weather_city 大阪"},
+			wantReply: "天気予報の地域を",
+			check: func(t *testing.T, settings *GuildSettings) {
+				if settings.WeatherCityID != 270000 {
+					t.Errorf("WeatherCityID = %d, want 270000", settings.WeatherCityID)
+				}
+			},
+		},
+		{
+			name:      "住所で天気予報の地域を指定",
+			messages:  []string{"/config weather_city 東京都新宿区"},
+			wantReply: "天気予報の地域を",
+			check: func(t *testing.T, settings *GuildSettings) {
+				if settings.WeatherCityID != 130010 {
+					t.Errorf("WeatherCityID = %d, want 130010", settings.WeatherCityID)
+				}
+			},
+		},
+		{
+			name:      "ローマ字の書き間違いでも天気予報の地域を指定",
+			messages:  []string{"/config weather_city tokyou"},
+			wantReply: "天気予報の地域を",
+			check: func(t *testing.T, settings *GuildSettings) {
+				if settings.WeatherCityID != 130010 {
+					t.Errorf("WeatherCityID = %d, want 130010", settings.WeatherCityID)
+				}
+			},
+		},
+		{
+			name:      "例は変更したプレフィックスで表示する",
+			messages:  []string{"/config prefix !", "!config prefix"},
+			wantReply: "例: !config prefix !",
+			check:     func(t *testing.T, settings *GuildSettings) {},
+		},
+		{
+			name:      "地域の例も変更したプレフィックスで表示する",
+			messages:  []string{"/config prefix !", "!config weather_city 0"},
+			wantReply: "例: !config weather_city 270000 や !config weather_city 大阪",
+			check:     func(t *testing.T, settings *GuildSettings) {},
+		},
+		{
+			name:      "configはお休みできない",
+			messages:  []string{"/config disable config"},
+			wantReply: "/config はお休みできないよ！",
+			check: func(t *testing.T, settings *GuildSettings) {
+				if len(settings.DisabledCommands) != 0 {
+					t.Errorf("DisabledCommands = %v, want none", settings.DisabledCommands)
+				}
+			},
+		},
+		{
+			name:      "最初の状態に戻す",
+			messages:  []string{"/config language en", "/config disable ping", "/config reset"},
+			wantReply: "このサーバーの設定を最初の状態に戻したよ！",
+			check: func(t *testing.T, settings *GuildSettings) {
+				if settings.Language != "" || len(settings.DisabledCommands) != 0 {
+					t.Errorf("settings = %+v, want reset", settings)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fake := newTestBot(t)
+			var sent []string
+			for _, content := range tt.messages {
+				for _, msg := range post(b, fake, testAdmin, content) {
+					sent = append(sent, msg.Content)
+				}
+			}
+			if len(sent) == 0 || !strings.Contains(sent[len(sent)-1], tt.wantReply) {
+				t.Fatalf("replies = %q, want the last one to contain %q", sent, tt.wantReply)
+			}
+			tt.check(t, b.guildSettings(testGuildID))
+		})
+	}
+}
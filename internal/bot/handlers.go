@@ -1,164 +1,255 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"kizuna_bot_go/internal/api"
+	"kizuna_bot_go/internal/config"
+	"kizuna_bot_go/internal/logging"
+	"kizuna_bot_go/internal/metrics"
 )
 
-// handleWeather sends weather information
-func (b *KizunaBot) handleWeather(s *discordgo.Session, m *discordgo.MessageCreate) {
-	message, err := b.apiClient.GetWeather()
-	if err != nil {
-		log.Printf("Error getting weather: %v", err)
-		message = "天気情報の取得に失敗しました。しばらく時間をおいてからお試しください。"
-	}
-	s.ChannelMessageSend(m.ChannelID, message)
-}
-
 // handleNews sends news information
-func (b *KizunaBot) handleNews(s *discordgo.Session, m *discordgo.MessageCreate) {
-	message, err := b.apiClient.GetNews()
+func (b *KizunaBot) handleNews(ctx *CommandContext) {
+	message, err := b.apiClient.GetNewsContext(ctx.Context)
 	if err != nil {
-		log.Printf("Error getting news: %v", err)
-		message = "ニュース取得に失敗しました。しばらく時間をおいてからお試しください。"
+		ctx.Logger().Log(ctx.Context, apiErrorLevel(err), "ニュースの取得に失敗しました", "error", err)
+		message = apiErrorMessage(err, "ニュース")
 	}
-	s.ChannelMessageSend(m.ChannelID, message)
+	ctx.Reply(message)
 }
 
 // handleDice rolls a dice
-func (b *KizunaBot) handleDice(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+func (b *KizunaBot) handleDice(ctx *CommandContext) {
 	max := 6 // Default to 6-sided dice
 
-	if len(args) > 0 {
-		if val, err := strconv.Atoi(args[0]); err == nil && val > 0 {
+	if len(ctx.Args) > 0 {
+		if val, err := strconv.Atoi(ctx.Args[0]); err == nil && val > 0 {
 			max = val
 		}
 	}
 
 	result := rand.Intn(max) + 1
 	message := fmt.Sprintf("%d面サイコロを回したら、「%d」が出たよ！", max, result)
-	s.ChannelMessageSend(m.ChannelID, message)
+	ctx.Reply(message)
 }
 
 // handleGourmet searches for restaurants
-func (b *KizunaBot) handleGourmet(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+func (b *KizunaBot) handleGourmet(ctx *CommandContext) {
 	address := ""
 	keyword := ""
 
-	if len(args) > 0 {
-		address = args[0]
+	if len(ctx.Args) > 0 {
+		address = ctx.Args[0]
 	}
-	if len(args) > 1 {
-		keyword = strings.Join(args[1:], " ")
+	if len(ctx.Args) > 1 {
+		keyword = strings.Join(ctx.Args[1:], " ")
+	}
+	// 地域が省略された場合は、ユーザーのよくいる場所かギルドで設定された地域を使う
+	// どちらもなければAPIクライアントの既定の地域（渋谷駅）で探す
+	if address == "" {
+		address = b.userSettings(ctx.Author.ID).Home
+	}
+	if address == "" {
+		address = ctx.Guild.GourmetArea
 	}
 
-	message, err := b.apiClient.GetGourmet(address, keyword)
+	message, err := b.apiClient.GetGourmetContext(ctx.Context, address, keyword)
 	if err != nil {
-		log.Printf("Error getting gourmet info: %v", err)
-		message = "グルメ検索に失敗しました。しばらく時間をおいてからお試しください。"
+		ctx.Logger().Log(ctx.Context, apiErrorLevel(err), "グルメ検索に失敗しました", "error", err)
+		message = apiErrorMessage(err, "お店")
 	}
-	s.ChannelMessageSend(m.ChannelID, message)
+	ctx.Reply(message)
 }
 
 // handleImage はGoogle Custom Search APIで画像を検索
-func (b *KizunaBot) handleImage(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	query := strings.Join(args, " ")
-	message, err := b.apiClient.GetImageSearch(query)
+func (b *KizunaBot) handleImage(ctx *CommandContext) {
+	query := strings.Join(ctx.Args, " ")
+	message, err := b.apiClient.GetImageSearchContext(ctx.Context, query, ctx.Guild.Lang())
 	if err != nil {
-		log.Printf("画像検索エラー: %v", err)
-		message = "画像検索に失敗しました。しばらく時間をおいてからお試しください。"
+		ctx.Logger().Log(ctx.Context, apiErrorLevel(err), "画像検索に失敗しました", "error", err)
+		message = apiErrorMessage(err, "画像")
+	}
+	ctx.Reply(message)
+}
+
+// handleQuota は1日の利用上限のある外部APIの今日の利用状況を表示
+func (b *KizunaBot) handleQuota(ctx *CommandContext) {
+	message := "今日の検索の残り回数だよ！\n"
+	for _, status := range b.apiClient.QuotaStatus() {
+		resetIn := formatResetIn(time.Until(status.ResetAt))
+		if status.Limit == 0 {
+			message += fmt.Sprintf("%s: %d%s（上限なし）\n", status.Name, status.Used, status.Unit)
+			continue
+		}
+		message += fmt.Sprintf("%s: %d / %d%s（あと%sでリセット）\n", status.Name, status.Used, status.Limit, status.Unit, resetIn)
+	}
+	ctx.Reply(strings.TrimSuffix(message, "\n"))
+}
+
+// formatResetIn はリセットまでの時間を「3時間20分」のような文字列にする
+func formatResetIn(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%d分", minutes)
 	}
-	s.ChannelMessageSend(m.ChannelID, message)
+	return fmt.Sprintf("%d時間%d分", minutes/60, minutes%60)
+}
+
+// apiErrorLevel は外部APIのエラーをログに出力する時のレベルを返す
+// 入力の問題や結果がなかっただけの場合は、ボットの異常ではないのでInfoにする
+func apiErrorLevel(err error) slog.Level {
+	switch {
+	case errors.Is(err, api.ErrInvalidInput), errors.Is(err, api.ErrNoResults):
+		return slog.LevelInfo
+	case errors.Is(err, api.ErrQuotaExhausted), errors.Is(err, api.ErrCircuitOpen):
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
+// apiErrorMessage は外部APIのエラーを、ユーザーが次にどうすればよいか分かるメッセージにする
+// whatには取得しようとしたもの（「天気情報」「お店」など）を指定する
+func apiErrorMessage(err error, what string) string {
+	var inputErr *api.InputError
+	switch {
+	case errors.As(err, &inputErr):
+		return inputErr.Hint
+	case errors.Is(err, api.ErrNoResults):
+		return fmt.Sprintf("ごめんね、%sが見つからなかったよ……言葉を変えてもう一度試してみてね", what)
+	case errors.Is(err, api.ErrQuotaExhausted):
+		return "今日はもう検索できる回数を使い切っちゃった……また明日お願いね :sleeping:"
+	case errors.Is(err, api.ErrMissingAPIKey):
+		return fmt.Sprintf("%sを取ってくるための鍵（APIキー）が使えないみたい……管理者さんに伝えてね :bow:", what)
+	case errors.Is(err, api.ErrCircuitOpen):
+		return "いま相手のサービスの調子が悪いみたい……少し時間をおいてからまた試してね :bow:"
+	case errors.Is(err, api.ErrUpstreamUnavailable):
+		return fmt.Sprintf("%sをうまく取ってこられなかったよ……しばらく時間をおいてからもう一度試してね", what)
+	}
+	return fmt.Sprintf("ごめんね、%sの取得に失敗しちゃった……しばらく時間をおいてからお試しください", what)
 }
 
 // handleRank はチャンネル内のユーザーアクティビティランキングを表示
-func (b *KizunaBot) handleRank(s *discordgo.Session, m *discordgo.MessageCreate) {
-	message, err := b.apiClient.GetUserRanking(s, m.ChannelID)
+func (b *KizunaBot) handleRank(ctx *CommandContext) {
+	message, err := b.apiClient.GetUserRankingContext(ctx.Context, b.messenger, ctx.ChannelID)
 	if err != nil {
-		log.Printf("ランキング取得エラー: %v", err)
-		message = "ランキングの取得に失敗しました。しばらく時間をおいてからお試しください。"
+		ctx.Logger().Log(ctx.Context, apiErrorLevel(err), "ランキングの取得に失敗しました", "error", err)
+		message = apiErrorMessage(err, "ランキング")
 	}
-	s.ChannelMessageSend(m.ChannelID, message)
+	ctx.Reply(message)
 }
 
 // handleTranslate はテキストを指定された言語に翻訳
-func (b *KizunaBot) handleTranslate(s *discordgo.Session, m *discordgo.MessageCreate, args []string, targetLang string) {
-	text := strings.Join(args, " ")
-	message, err := b.apiClient.GetTranslation(text, targetLang)
+func (b *KizunaBot) handleTranslate(ctx *CommandContext, targetLang string) {
+	text := strings.Join(ctx.Args, " ")
+	message, err := b.apiClient.GetTranslationContext(ctx.Context, text, targetLang)
 	if err != nil {
-		log.Printf("翻訳エラー: %v", err)
-		message = "翻訳に失敗しました。しばらく時間をおいてからお試しください。"
+		ctx.Logger().Log(ctx.Context, apiErrorLevel(err), "翻訳に失敗しました", "error", err)
+		message = apiErrorMessage(err, "翻訳")
 	}
-	s.ChannelMessageSend(m.ChannelID, message)
+	ctx.Reply(message)
 }
 
 // handleVideo はYouTubeから動画を検索
-func (b *KizunaBot) handleVideo(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	query := strings.Join(args, " ")
-	message, err := b.apiClient.GetVideoSearch(query)
+func (b *KizunaBot) handleVideo(ctx *CommandContext) {
+	query := strings.Join(ctx.Args, " ")
+	message, err := b.apiClient.GetVideoSearchContext(ctx.Context, query, ctx.Guild.Lang())
 	if err != nil {
-		log.Printf("動画検索エラー: %v", err)
-		message = "動画検索に失敗しました。しばらく時間をおいてからお試しください。"
+		ctx.Logger().Log(ctx.Context, apiErrorLevel(err), "動画検索に失敗しました", "error", err)
+		message = apiErrorMessage(err, "動画")
 	}
-	s.ChannelMessageSend(m.ChannelID, message)
+	ctx.Reply(message)
 }
 
 // handleVTuber はVTuber動画を検索
-func (b *KizunaBot) handleVTuber(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+func (b *KizunaBot) handleVTuber(ctx *CommandContext) {
 	// Ruby版と同様に"VTuber "を前に付けて検索
-	query := "VTuber " + strings.Join(args, " ")
-	message, err := b.apiClient.GetVideoSearch(query)
+	query := "VTuber " + strings.Join(ctx.Args, " ")
+	message, err := b.apiClient.GetVideoSearchContext(ctx.Context, query, ctx.Guild.Lang())
 	if err != nil {
-		log.Printf("VTuber動画検索エラー: %v", err)
-		message = "VTuber動画検索に失敗しました。しばらく時間をおいてからお試しください。"
+		ctx.Logger().Log(ctx.Context, apiErrorLevel(err), "VTuber動画検索に失敗しました", "error", err)
+		message = apiErrorMessage(err, "VTuberの動画")
 	}
-	s.ChannelMessageSend(m.ChannelID, message)
+	ctx.Reply(message)
 }
 
 // getMunouMessage はメンション時の応答メッセージを生成
-func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *discordgo.MessageCreate) string {
+func (b *KizunaBot) getMunouMessage(ctx context.Context, message string, m *discordgo.MessageCreate) string {
 	content := strings.ToLower(message)
+	// 外部APIの失敗はコマンドの場合と同じくログに残す
+	logger := logging.FromContext(ctx)
+
+	// どの話題として応答したかをメトリクスとして数える
+	var intent string
+	defer func() { metrics.MunouIntents.WithLabelValues(intent).Inc() }()
 
 	switch {
 	case strings.Contains(content, "英語で"):
+		intent = "translate_en"
 		// 「」で囲まれたテキストを英語に翻訳
-		if result, err := b.apiClient.GetTranslationWithQuotes(m.Content, "en"); err == nil {
-			return result
+		result, err := b.apiClient.GetTranslationWithQuotesContext(ctx, m.Content, "en")
+		if err != nil {
+			logger.Log(ctx, apiErrorLevel(err), "翻訳に失敗しました", "error", err)
+			return apiErrorMessage(err, "翻訳")
 		}
-		return "翻訳に失敗しました"
+		return result
 	case strings.Contains(content, "日本語で"):
+		intent = "translate_ja"
 		// 「」で囲まれたテキストを日本語に翻訳
-		if result, err := b.apiClient.GetTranslationWithQuotes(m.Content, "ja"); err == nil {
-			return result
+		result, err := b.apiClient.GetTranslationWithQuotesContext(ctx, m.Content, "ja")
+		if err != nil {
+			logger.Log(ctx, apiErrorLevel(err), "翻訳に失敗しました", "error", err)
+			return apiErrorMessage(err, "翻訳")
 		}
-		return "翻訳に失敗しました"
+		return result
 	case strings.Contains(content, "天気"):
+		intent = "weather"
 		// メンション応答での天気機能
-		if weather, err := b.apiClient.GetWeather(); err == nil {
-			return weather
+		cityID, reply := b.weatherCityForMessage(message, m.Author.ID, b.guildSettings(m.GuildID))
+		if reply != "" {
+			return reply
+		}
+		weather, err := b.apiClient.GetWeatherReportContext(ctx, cityID, b.weatherOptionsForMessage(message, m.Author.ID))
+		if err != nil {
+			logger.Log(ctx, apiErrorLevel(err), "天気情報の取得に失敗しました", "error", err)
+			return apiErrorMessage(err, "天気情報")
 		}
-		return "天気情報の取得に失敗しました"
+		return weather
 	case strings.Contains(content, "さいころ") || strings.Contains(content, "サイコロ"):
+		intent = "dice"
 		result := rand.Intn(6) + 1
 		return fmt.Sprintf("6面サイコロを回したら、「%d」が出たよ！", result)
 	case strings.Contains(content, "ニュース"):
+		intent = "news"
 		// メンション応答でのニュース機能
-		if news, err := b.apiClient.GetNews(); err == nil {
-			return news
+		if !b.cfg().Enabled(config.FeatureNews) {
+			return featureUnavailableMessage("ニュース")
 		}
-		return "ニュース取得に失敗しました"
+		news, err := b.apiClient.GetNewsContext(ctx)
+		if err != nil {
+			logger.Log(ctx, apiErrorLevel(err), "ニュースの取得に失敗しました", "error", err)
+			return apiErrorMessage(err, "ニュース")
+		}
+		return news
 	case strings.Contains(content, "ランキング"):
+		intent = "ranking"
 		// メンション応答でのランキング機能
-		if ranking, err := b.apiClient.GetUserRanking(s, m.ChannelID); err == nil {
-			return ranking
+		ranking, err := b.apiClient.GetUserRankingContext(ctx, b.messenger, m.ChannelID)
+		if err != nil {
+			logger.Log(ctx, apiErrorLevel(err), "ランキングの取得に失敗しました", "error", err)
+			return apiErrorMessage(err, "ランキング")
 		}
-		return "ランキングの取得に失敗しました"
+		return ranking
 	case strings.Contains(content, "おなかすいた") || strings.Contains(content, "おなすき"):
+		intent = "hungry"
 		responses := []string{
 			"栄養あるものをしっかり食べようね！",
 			"ぐぐぅぅー",
@@ -166,6 +257,7 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "おはよ"):
+		intent = "good_morning"
 		responses := []string{
 			"おはよう〜！",
 			"きょうもがんばろうね！",
@@ -174,8 +266,10 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "こんにち"):
+		intent = "hello"
 		return "こんにちは〜！"
 	case strings.Contains(content, "おやすみ"):
+		intent = "good_night"
 		responses := []string{
 			"おやすみ〜",
 			"ｚｚｚ。。。。。",
@@ -185,6 +279,7 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "ねむい") || strings.Contains(content, "眠い"):
+		intent = "sleepy"
 		responses := []string{
 			"だよねわかる・・・・",
 			"うとうと・・・・",
@@ -193,12 +288,14 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "元気？"):
+		intent = "how_are_you"
 		responses := []string{
 			"うん！ ありがと！",
 			"元気だよー！！",
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "かわい"):
+		intent = "cute"
 		responses := []string{
 			"えへへ :heartbeat:",
 			"そ……そうかな…",
@@ -207,8 +304,10 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "大好き") || strings.Contains(content, "だいすき"):
+		intent = "love"
 		return "私もだよ！"
 	case strings.Contains(content, "好き") || strings.Contains(content, "すき"):
+		intent = "like"
 		responses := []string{
 			"いいねいいね！！ :sparkles: :sparkles:",
 			"わたしもわたしも！ :white_flower:",
@@ -216,6 +315,7 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "愛して") || strings.Contains(content, "あいして"):
+		intent = "aishiteru"
 		responses := []string{
 			"えっ………",
 			"ちょっと気持ち悪い",
@@ -227,6 +327,7 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "ありがと"):
+		intent = "thanks"
 		responses := []string{
 			"どういたしまして！",
 			"いえいえ〜〜",
@@ -234,6 +335,7 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "がんば"):
+		intent = "cheer"
 		responses := []string{
 			"いっしょにがんばろー！",
 			"楽しい日になるといいね！",
@@ -242,6 +344,7 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "くまくま"):
+		intent = "kumakuma"
 		responses := []string{
 			"ざわ……ざわ……",
 			"ʕ•̀ω•́ʔ  ʕ•̀ω•́ʔ  ʕ•̀ω•́ʔ  ʕ•̀ω•́ʔ",
@@ -254,6 +357,7 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "疲れ") || strings.Contains(content, "つかれ"):
+		intent = "tired"
 		responses := []string{
 			"よしよし・・・ ( ,,´・ω・)ﾉ (´っω・｀｡)",
 			"すこし休もうねー？ ヾ(´ー｀*)",
@@ -261,6 +365,7 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "つらい") || strings.Contains(content, "ちゅらい"):
+		intent = "hard_times"
 		responses := []string{
 			"わかる・・・",
 			"5000兆円あげるから元気だして",
@@ -269,6 +374,7 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "死に"):
+		intent = "dying"
 		responses := []string{
 			"生きてーーーーーっっっっ！！！！ (> <!!!!",
 			"へんじがない、ただのしかばねのようだ",
@@ -277,6 +383,7 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "にゃん") || strings.Contains(content, "にゃー"):
+		intent = "nyan"
 		responses := []string{
 			"にゃ〜ん :cat2:",
 			"わかるにゃ・・・・・",
@@ -285,14 +392,22 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "ひま") || strings.Contains(content, "ヒマ") || strings.Contains(content, "暇"):
+		intent = "bored"
 		// Ruby版と同様に「ひま」でニュースを返す
-		if news, err := b.apiClient.GetNews(); err == nil {
-			return news
+		if !b.cfg().Enabled(config.FeatureNews) {
+			return featureUnavailableMessage("ニュース")
+		}
+		news, err := b.apiClient.GetNewsContext(ctx)
+		if err != nil {
+			logger.Log(ctx, apiErrorLevel(err), "ニュースの取得に失敗しました", "error", err)
+			return apiErrorMessage(err, "ニュース")
 		}
-		return "ニュース取得に失敗しました"
+		return news
 	case strings.Contains(content, "アニメ"):
+		intent = "anime"
 		return "アニメといえばキルミーベイベーだよね！"
 	case strings.HasSuffix(content, "！！") || strings.HasSuffix(content, "!!"):
+		intent = "excited"
 		responses := []string{
 			"そうだね！！！",
 			"元気いっぱいだねー！！",
@@ -300,12 +415,19 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "ゆーま") && (strings.HasSuffix(content, "？") || strings.HasSuffix(content, "?")):
+		intent = "yuma"
 		// Ruby版と同じ特定チャンネル（ゆーま）の動画検索
-		if videoURL, err := b.apiClient.GetVideoByChannel("UC_9DxYZ_4Lhm9ujFvcHryNw"); err == nil {
-			return fmt.Sprintf("ゆーまってこの人かな？！ (੭ु ›ω‹ )੭ु⁾⁾ %s", videoURL)
+		if !b.cfg().Enabled(config.FeatureVideo) {
+			return featureUnavailableMessage("動画検索")
+		}
+		videoURL, err := b.apiClient.GetVideoByChannelContext(ctx, "UC_9DxYZ_4Lhm9ujFvcHryNw")
+		if err != nil {
+			logger.Log(ctx, apiErrorLevel(err), "動画検索に失敗しました", "error", err)
+			return apiErrorMessage(err, "ゆーまの動画")
 		}
-		return "ゆーまの動画が見つからなかったよ"
+		return fmt.Sprintf("ゆーまってこの人かな？！ (੭ु ›ω‹ )੭ु⁾⁾ %s", videoURL)
 	case strings.HasSuffix(content, "？") || strings.HasSuffix(content, "?"):
+		intent = "question"
 		responses := []string{
 			"そうかも？",
 			"わからぬ〜",
@@ -315,9 +437,11 @@ func (b *KizunaBot) getMunouMessage(message string, s *discordgo.Session, m *dis
 		}
 		return responses[rand.Intn(len(responses))]
 	case strings.Contains(content, "help"):
+		intent = "help"
 		// Return help message
 		return "コマンドについては /help を使ってね！"
 	default:
+		intent = "chat"
 		responses := []string{
 			"なるほど〜",
 			"それそれ！！",
@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"kizuna_bot_go/internal/api"
+	"kizuna_bot_go/internal/config"
+)
+
+func TestHandleGourmetArea(t *testing.T) {
+	tests := []struct {
+		name      string
+		home      string // ユーザーが /home で登録した場所
+		guildArea string // /config gourmet_area で設定した地域
+		content   string
+		want      string
+	}{
+		{name: "指定した地域", home: "新宿", guildArea: "池袋", content: "/gourmet 上野 焼肉", want: "上野"},
+		{name: "よくいる場所", home: "新宿", guildArea: "池袋", content: "/gourmet", want: "新宿"},
+		{name: "サーバーの設定の地域", guildArea: "池袋", content: "/gourmet", want: "池袋"},
+		{name: "どちらもなければ渋谷駅", content: "/gourmet", want: "渋谷駅"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var addresses []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				addresses = append(addresses, r.URL.Query().Get("address"))
+				mu.Unlock()
+				w.Write([]byte(`{"results":{"shop":[]}}`))
+			}))
+			defer server.Close()
+
+			b, fake := newTestBot(t, func(cfg *config.Config) {
+				cfg.HotPepperAPIHost = server.URL
+				cfg.RecruitAPIKey = "key"
+			})
+			if tt.home != "" {
+				post(b, fake, testUser, "/home set "+tt.home)
+			}
+			if tt.guildArea != "" {
+				post(b, fake, testAdmin, "/config gourmet_area "+tt.guildArea)
+			}
+
+			post(b, fake, testUser, tt.content)
+			mu.Lock()
+			defer mu.Unlock()
+			if len(addresses) != 1 || addresses[0] != tt.want {
+				t.Errorf("searched %q, want [%q]", addresses, tt.want)
+			}
+		})
+	}
+}
+
+// upstreamError は外部APIがstatusを返した時に、天気予報の取得で返るエラーを作る
+func upstreamError(t *testing.T, status int) error {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	cfg.LivedoorWeatherAPIHost = server.URL
+	cfg.Cache.TTLs = nil
+	cfg.Retry.MaxAttempts = 1
+
+	_, err = api.NewClient(cfg, nil).GetWeatherByCityContext(context.Background(), 130010)
+	if err == nil {
+		t.Fatalf("status %d: no error", status)
+	}
+	return err
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       func(t *testing.T) error
+		want      string // 返答に含まれるべき文字列
+		wantLevel slog.Level
+	}{
+		{
+			name:      "入力の問題はヒントをそのまま返す",
+			err:       func(t *testing.T) error { return &api.InputError{Hint: "地域を指定してね！"} },
+			want:      "地域を指定してね！",
+			wantLevel: slog.LevelInfo,
+		},
+		{
+			name: "包まれた入力の問題",
+			err: func(t *testing.T) error {
+				return fmt.Errorf("gourmet: %w", &api.InputError{Hint: "地域を指定してね！"})
+			},
+			want:      "地域を指定してね！",
+			wantLevel: slog.LevelInfo,
+		},
+		{
+			name:      "結果なし",
+			err:       func(t *testing.T) error { return fmt.Errorf("weather: %w", api.ErrNoResults) },
+			want:      "ごめんね、天気情報が見つからなかったよ",
+			wantLevel: slog.LevelInfo,
+		},
+		{
+			name:      "利用上限",
+			err:       func(t *testing.T) error { return fmt.Errorf("image: %w", api.ErrQuotaExhausted) },
+			want:      "今日はもう検索できる回数を使い切っちゃった",
+			wantLevel: slog.LevelWarn,
+		},
+		{
+			name:      "APIキー",
+			err:       func(t *testing.T) error { return fmt.Errorf("news: %w", api.ErrMissingAPIKey) },
+			want:      "天気情報を取ってくるための鍵（APIキー）が使えないみたい",
+			wantLevel: slog.LevelError,
+		},
+		{
+			name:      "接続先の不調",
+			err:       func(t *testing.T) error { return fmt.Errorf("news: %w", api.ErrUpstreamUnavailable) },
+			want:      "天気情報をうまく取ってこられなかったよ",
+			wantLevel: slog.LevelError,
+		},
+		{
+			name:      "呼び出しを止めている接続先",
+			err:       func(t *testing.T) error { return fmt.Errorf("weather: %w", api.ErrCircuitOpen) },
+			want:      "いま相手のサービスの調子が悪いみたい",
+			wantLevel: slog.LevelWarn,
+		},
+		{
+			name:      "401はAPIキーの問題",
+			err:       func(t *testing.T) error { return upstreamError(t, http.StatusUnauthorized) },
+			want:      "鍵（APIキー）が使えないみたい",
+			wantLevel: slog.LevelError,
+		},
+		{
+			name:      "403はAPIキーの問題",
+			err:       func(t *testing.T) error { return upstreamError(t, http.StatusForbidden) },
+			want:      "鍵（APIキー）が使えないみたい",
+			wantLevel: slog.LevelError,
+		},
+		{
+			name:      "429は接続先の不調",
+			err:       func(t *testing.T) error { return upstreamError(t, http.StatusTooManyRequests) },
+			want:      "天気情報をうまく取ってこられなかったよ",
+			wantLevel: slog.LevelError,
+		},
+		{
+			name:      "5xxは接続先の不調",
+			err:       func(t *testing.T) error { return upstreamError(t, http.StatusBadGateway) },
+			want:      "天気情報をうまく取ってこられなかったよ",
+			wantLevel: slog.LevelError,
+		},
+		{
+			name:      "分類できないエラー",
+			err:       func(t *testing.T) error { return upstreamError(t, http.StatusNotFound) },
+			want:      "ごめんね、天気情報の取得に失敗しちゃった",
+			wantLevel: slog.LevelError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err(t)
+			if got := apiErrorMessage(err, "天気情報"); !strings.Contains(got, tt.want) {
+				t.Errorf("apiErrorMessage(%v) = %q, want it to contain %q", err, got, tt.want)
+			}
+			if got := apiErrorLevel(err); got != tt.wantLevel {
+				t.Errorf("apiErrorLevel(%v) = %v, want %v", err, got, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestCircuitOpenIsUpstreamUnavailable(t *testing.T) {
+	err := fmt.Errorf("weather: %w", api.ErrCircuitOpen)
+	if !errors.Is(err, api.ErrUpstreamUnavailable) {
+		t.Error("ErrCircuitOpen does not match ErrUpstreamUnavailable")
+	}
+	if errors.Is(api.ErrUpstreamUnavailable, api.ErrCircuitOpen) {
+		t.Error("ErrUpstreamUnavailable matches ErrCircuitOpen")
+	}
+}
+
+func TestMentionLogsAPIFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	b, fake := newTestBot(t, func(cfg *config.Config) {
+		cfg.LivedoorWeatherAPIHost = server.URL
+		cfg.Cache.TTLs = nil
+		cfg.Retry.MaxAttempts = 1
+	})
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	self := fake.Self()
+	msg := fake.AddMessage(&discordgo.Message{
+		ChannelID: testChannelID,
+		GuildID:   testGuildID,
+		Content:   fmt.Sprintf("<@%s> 今日の天気は？", self.ID),
+		Author:    testUser,
+		Mentions:  []*discordgo.User{self},
+	})
+	before := len(fake.Sent())
+	b.handleMessage(&discordgo.MessageCreate{Message: msg}, self.ID)
+
+	sent := fake.Sent()[before:]
+	if len(sent) != 1 || !strings.Contains(sent[0].Content, "天気情報をうまく取ってこられなかったよ") {
+		t.Fatalf("reply to the mention = %v", sent)
+	}
+	if !strings.Contains(logs.String(), "level=ERROR msg=天気情報の取得に失敗しました") {
+		t.Errorf("the failure was not logged:\n%s", logs.String())
+	}
+}
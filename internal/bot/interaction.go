@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"kizuna_bot_go/internal/logging"
+	"kizuna_bot_go/internal/textutil"
+)
+
+// applicationCommandDescriptionLimit はアプリケーションコマンドの説明文に使える最大文字数
+const applicationCommandDescriptionLimit = 100
+
+// emojiShortcodePattern は「:game_die:」のような絵文字ショートコードにマッチする
+// アプリケーションコマンドの説明文では絵文字として表示されないため取り除く
+var emojiShortcodePattern = regexp.MustCompile(`\s*:[a-z0-9_+\-]+:`)
+
+// registerApplicationCommands はレジストリ内の全コマンドをDiscordのアプリケーションコマンドとして登録
+// 設定でギルドIDが指定されていればそのギルドのみ、空ならグローバルに登録する
+func (b *KizunaBot) registerApplicationCommands(s *discordgo.Session) {
+	var appCommands []*discordgo.ApplicationCommand
+	for _, cmd := range b.commands.Commands() {
+		appCommands = append(appCommands, &discordgo.ApplicationCommand{
+			Name:        cmd.Name(),
+			Description: applicationCommandDescription(cmd.Description()),
+			Options:     cmd.Options(),
+		})
+	}
+
+	guildID := b.cfg().ApplicationCommandGuildID
+	if _, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, guildID, appCommands); err != nil {
+		slog.Error("アプリケーションコマンドの登録に失敗しました", "error", err)
+		return
+	}
+
+	if guildID == "" {
+		slog.Info("アプリケーションコマンドをグローバルに登録しました", "count", len(appCommands))
+	} else {
+		slog.Info("アプリケーションコマンドをギルドに登録しました", "guild_id", guildID, "count", len(appCommands))
+	}
+}
+
+// interactionCreate はアプリケーションコマンドが実行された時に呼ばれるイベントハンドラー
+func (b *KizunaBot) interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	cmd, ok := b.commands.Find(data.Name)
+	if !ok {
+		return
+	}
+
+	// 終了処理が始まった後に実行されたコマンドは処理しない
+	done, ok := b.handlers.begin(fmt.Sprintf("チャンネル %s のアプリケーションコマンド /%s", i.ChannelID, data.Name))
+	if !ok {
+		return
+	}
+	defer done()
+
+	// ギルド内ではMemberに、DMではUserに実行したユーザーの情報が入っている
+	author := i.User
+	if i.Member != nil {
+		author = i.Member.User
+	}
+
+	// コマンドの処理が終わるか、ボットが終了するまで有効なコンテキスト
+	// このコマンドに関するログには、同じ相関IDと実行した場所・ユーザーが付く
+	ctx, cancel := context.WithCancel(b.ctx)
+	defer cancel()
+	ctx = logging.With(ctx,
+		"correlation_id", logging.NewCorrelationID(),
+		"interaction_id", i.ID,
+		"guild_id", i.GuildID,
+		"channel_id", i.ChannelID,
+		"user_id", author.ID,
+	)
+
+	// 外部APIの呼び出しは3秒を超えることがあるため、先に「考え中」の応答を返しておく
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("アプリケーションコマンドへの応答に失敗しました", "error", err)
+		return
+	}
+
+	b.executeCommand(cmd, &CommandContext{
+		Context:     ctx,
+		Messenger:   b.messenger,
+		Session:     s,
+		Interaction: i,
+		ChannelID:   i.ChannelID,
+		GuildID:     i.GuildID,
+		Author:      author,
+		Member:      i.Member,
+		Guild:       b.guildSettings(i.GuildID),
+		Name:        data.Name,
+		Args:        argsFromOptions(cmd.Options(), data.Options),
+	})
+}
+
+// argsFromOptions はアプリケーションコマンドの引数を、テキストコマンドと同じ並びの引数リストに変換
+// 途中の省略された引数は空文字列で埋め、末尾の省略された引数は含めない
+func argsFromOptions(defs []*discordgo.ApplicationCommandOption, values []*discordgo.ApplicationCommandInteractionDataOption) []string {
+	byName := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, value := range values {
+		byName[value.Name] = value
+	}
+
+	args := make([]string, len(defs))
+	last := 0
+	for i, def := range defs {
+		value, ok := byName[def.Name]
+		if !ok {
+			continue
+		}
+
+		switch value.Type {
+		case discordgo.ApplicationCommandOptionInteger:
+			args[i] = strconv.FormatInt(value.IntValue(), 10)
+		case discordgo.ApplicationCommandOptionBoolean:
+			args[i] = strconv.FormatBool(value.BoolValue())
+		default:
+			args[i] = value.StringValue()
+		}
+		last = i + 1
+	}
+
+	return args[:last]
+}
+
+// applicationCommandDescription はヘルプ用の説明文からアプリケーションコマンド用の説明文を作る
+func applicationCommandDescription(description string) string {
+	description = strings.TrimSpace(emojiShortcodePattern.ReplaceAllString(description, ""))
+	return textutil.Truncate(description, applicationCommandDescriptionLimit)
+}
@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestArgsFromOptions(t *testing.T) {
+	defs := []*discordgo.ApplicationCommandOption{
+		{Name: "area", Type: discordgo.ApplicationCommandOptionString},
+		{Name: "count", Type: discordgo.ApplicationCommandOptionInteger},
+		{Name: "detail", Type: discordgo.ApplicationCommandOptionBoolean},
+	}
+	str := func(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+	}
+	// DiscordはJSONで送ってくるため、整数もfloat64になっている
+	integer := func(name string, value float64) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionInteger, Value: value}
+	}
+	boolean := func(name string, value bool) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionBoolean, Value: value}
+	}
+
+	tests := []struct {
+		name   string
+		values []*discordgo.ApplicationCommandInteractionDataOption
+		want   []string
+	}{
+		{name: "引数なし", values: nil, want: []string{}},
+		{name: "全て指定", values: []*discordgo.ApplicationCommandInteractionDataOption{str("area", "新宿"), integer("count", 3), boolean("detail", true)}, want: []string{"新宿", "3", "true"}},
+		{name: "定義の順に並べる", values: []*discordgo.ApplicationCommandInteractionDataOption{boolean("detail", false), str("area", "渋谷")}, want: []string{"渋谷", "", "false"}},
+		{name: "途中の省略は空文字列で埋める", values: []*discordgo.ApplicationCommandInteractionDataOption{integer("count", 5), boolean("detail", true)}, want: []string{"", "5", "true"}},
+		{name: "末尾の省略は含めない", values: []*discordgo.ApplicationCommandInteractionDataOption{str("area", "池袋")}, want: []string{"池袋"}},
+		{name: "整数", values: []*discordgo.ApplicationCommandInteractionDataOption{str("area", ""), integer("count", -12)}, want: []string{"", "-12"}},
+		{name: "知らない引数は無視", values: []*discordgo.ApplicationCommandInteractionDataOption{str("unknown", "x"), str("area", "上野")}, want: []string{"上野"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := argsFromOptions(defs, tt.values); !slices.Equal(got, tt.want) {
+				t.Errorf("argsFromOptions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplicationCommandDescription(t *testing.T) {
+	long := strings.Repeat("あ", applicationCommandDescriptionLimit+20)
+
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{name: "そのまま", description: "テスト用だよ", want: "テスト用だよ"},
+		{name: "絵文字のショートコードを取り除く", description: "天気を教えるよ :sunny: ", want: "天気を教えるよ"},
+		{name: "ちょうど上限", description: long[:len("あ")*applicationCommandDescriptionLimit], want: long[:len("あ")*applicationCommandDescriptionLimit]},
+		{name: "上限を超えたら「…」を含めて収める", description: long, want: strings.Repeat("あ", applicationCommandDescriptionLimit-1) + "…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applicationCommandDescription(tt.description)
+			if got != tt.want {
+				t.Errorf("applicationCommandDescription(%q) = %q, want %q", tt.description, got, tt.want)
+			}
+			if n := utf8.RuneCountInString(got); n > applicationCommandDescriptionLimit {
+				t.Errorf("description has %d runes, over Discord's limit of %d", n, applicationCommandDescriptionLimit)
+			}
+		})
+	}
+}
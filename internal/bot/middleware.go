@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"kizuna_bot_go/internal/logging"
+	"kizuna_bot_go/internal/metrics"
+)
+
+// コマンドの処理結果（メトリクスのラベルやログに使う）
+const (
+	resultExecuted    = "executed"    // 実行した
+	resultDisabled    = "disabled"    // ギルドの設定でお休み中だった
+	resultDenied      = "denied"      // ギルドのルールで使えなかった
+	resultUnavailable = "unavailable" // APIキーが足りずに使えなかった
+	resultCooldown    = "cooldown"    // 連続実行の制限にかかった
+	resultPanic       = "panic"       // 処理中にパニックが発生した
+)
+
+// Handler はコマンドやメンションへの応答を処理する関数
+type Handler func(ctx *CommandContext)
+
+// Middleware はHandlerを包み、前後に共通の処理を追加する
+// 処理を続ける場合はnextを呼び、断る場合は呼ばずに返す
+type Middleware func(next Handler) Handler
+
+// chain はhandlerをmiddlewaresで包む（middlewaresの先頭が一番外側になる）
+func chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// commandMiddlewares はコマンドの実行を包むミドルウェアを、外側から順に返す
+func (b *KizunaBot) commandMiddlewares() []Middleware {
+	return []Middleware{
+		recordMetrics,
+		logRequest,
+		recoverPanic,
+		b.checkAvailability,
+		checkPermissions,
+		b.enforceCooldown,
+		sendTyping,
+		markExecuted,
+	}
+}
+
+// replyMiddlewares はメンションやキーワードへの自動応答を包むミドルウェアを、外側から順に返す
+func (b *KizunaBot) replyMiddlewares() []Middleware {
+	return []Middleware{
+		logRequest,
+		recoverPanic,
+		sendTyping,
+		markExecuted,
+	}
+}
+
+// recordMetrics はコマンドの処理結果ごとの回数をメトリクスとして数える
+func recordMetrics(next Handler) Handler {
+	return func(ctx *CommandContext) {
+		next(ctx)
+		if ctx.Command != nil {
+			metrics.Commands.WithLabelValues(ctx.Command.Name(), ctx.result).Inc()
+		}
+	}
+}
+
+// logRequest は処理の開始と終了をログに出力する
+// コマンドの場合は、以降のログにコマンド名が付くようにする
+func logRequest(next Handler) Handler {
+	return func(ctx *CommandContext) {
+		if ctx.Command != nil {
+			ctx.Context = logging.With(ctx.Context, "command", ctx.Command.Name())
+			ctx.Logger().Info("コマンドを受け付けました", "args", ctx.Args)
+		} else {
+			ctx.Logger().Debug("メッセージに応答します")
+		}
+
+		start := time.Now()
+		next(ctx)
+		ctx.Logger().Debug("処理が終わりました", "result", ctx.result, "duration", time.Since(start))
+	}
+}
+
+// recoverPanic は処理中のパニックを回復し、ログに記録してからユーザーに謝る
+// discordgoのイベント処理のゴルーチンでパニックが起きると、ボット全体が終了してしまうため
+func recoverPanic(next Handler) Handler {
+	return func(ctx *CommandContext) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			ctx.result = resultPanic
+			ctx.Logger().Error("処理中にパニックが発生しました", "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+			ctx.Reply("ごめんね、うまくお返事できなかったみたい……もう一度試してみてね :bow:")
+		}()
+		next(ctx)
+	}
+}
+
+// checkAvailability はギルドでお休みにされているコマンドや、APIキーが足りずに使えないコマンドを断る
+func (b *KizunaBot) checkAvailability(next Handler) Handler {
+	return func(ctx *CommandContext) {
+		cmd := ctx.Command
+		if cmd == nil {
+			next(ctx)
+			return
+		}
+
+		// ギルドの設定でお休みにされているコマンドは実行しない
+		if ctx.Guild.IsDisabled(cmd.Name()) {
+			ctx.result = resultDisabled
+			ctx.Reply(fmt.Sprintf("このサーバーでは %s%s はお休み中だよ", ctx.Guild.CommandPrefix(), cmd.Name()))
+			return
+		}
+
+		// 必要なAPIキーが設定されていない機能は、APIを呼び出す前に断る
+		if !b.cfg().Enabled(cmd.Feature()) {
+			ctx.result = resultUnavailable
+			ctx.Reply(featureUnavailableMessage(ctx.Guild.CommandPrefix() + cmd.Name()))
+			return
+		}
+
+		next(ctx)
+	}
+}
+
+// checkPermissions はギルドで決められたルール（ロールやチャンネルなど）を満たしていないユーザーを断る
+func checkPermissions(next Handler) Handler {
+	return func(ctx *CommandContext) {
+		if ctx.Command != nil {
+			if refusal := checkCommandRule(ctx, ctx.Command); refusal != "" {
+				ctx.result = resultDenied
+				ctx.Reply(refusal)
+				return
+			}
+		}
+		next(ctx)
+	}
+}
+
+// enforceCooldown は短時間に何度も実行されたコマンドを断り、少し待ってもらう
+func (b *KizunaBot) enforceCooldown(next Handler) Handler {
+	return func(ctx *CommandContext) {
+		if ctx.Command != nil {
+			if wait := b.cooldownWait(ctx, ctx.Command); wait > 0 {
+				ctx.result = resultCooldown
+				ctx.Reply(fmt.Sprintf("ちょっと待ってね！ %s%s はあと%sくらいでまた使えるようになるよ (｡•́︿•̀｡)",
+					ctx.Guild.CommandPrefix(), ctx.Command.Name(), formatWait(wait)))
+				return
+			}
+		}
+		next(ctx)
+	}
+}
+
+// sendTyping は返答を用意している間、チャンネルに「入力中…」を表示する
+// アプリケーションコマンドの場合は、Discordが「考え中」を表示しているので何もしない
+func sendTyping(next Handler) Handler {
+	return func(ctx *CommandContext) {
+		if ctx.Interaction == nil {
+			if err := ctx.Messenger.Typing(ctx.ChannelID); err != nil {
+				ctx.Logger().Debug("入力中の表示に失敗しました", "error", err)
+			}
+		}
+		next(ctx)
+	}
+}
+
+// markExecuted は全ての確認を通過して処理を実行したことを記録する（一番内側に置く）
+func markExecuted(next Handler) Handler {
+	return func(ctx *CommandContext) {
+		ctx.result = resultExecuted
+		next(ctx)
+	}
+}
@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"kizuna_bot_go/internal/metrics"
+)
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx *CommandContext) {
+				calls = append(calls, name+"前")
+				next(ctx)
+				calls = append(calls, name+"後")
+			}
+		}
+	}
+
+	handler := chain(func(ctx *CommandContext) { calls = append(calls, "処理") }, record("外"), record("内"))
+	handler(&CommandContext{})
+
+	// 先頭のミドルウェアが一番外側になる
+	want := []string{"外前", "内前", "処理", "内後", "外後"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestChainStopsWhenMiddlewareRefuses(t *testing.T) {
+	refuse := func(next Handler) Handler {
+		return func(ctx *CommandContext) { ctx.result = resultDenied }
+	}
+	called := false
+
+	ctx := &CommandContext{}
+	chain(func(ctx *CommandContext) { called = true }, refuse, markExecuted)(ctx)
+
+	if called || ctx.result != resultDenied {
+		t.Errorf("called = %v, result = %q; want the handler skipped and result denied", called, ctx.result)
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	b, fake := newTestBot(t)
+	b.commands.Register(&simpleCommand{
+		name:        "explode",
+		description: "パニックを起こす",
+		handler:     func(ctx *CommandContext) { panic("どかーん") },
+	})
+	panics := metrics.Commands.WithLabelValues("explode", resultPanic)
+	before := testutil.ToFloat64(panics)
+
+	sent := post(b, fake, testUser, "/explode")
+	if len(sent) != 1 || !strings.HasPrefix(sent[0].Content, "ごめんね、うまくお返事できなかったみたい") {
+		t.Fatalf("reply to a panicking command = %v", sent)
+	}
+	if got := testutil.ToFloat64(panics) - before; got != 1 {
+		t.Errorf(`result="panic" was recorded %v times, want 1`, got)
+	}
+
+	// パニックの後も他のコマンドに応答し続ける
+	sent = post(b, fake, testUser, "/ping")
+	if len(sent) != 1 || !strings.HasPrefix(sent[0].Content, "Pong！") {
+		t.Errorf("reply to /ping after a panic = %v", sent)
+	}
+}
@@ -0,0 +1,202 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"kizuna_bot_go/internal/api"
+	"kizuna_bot_go/internal/metrics"
+)
+
+// monitorShutdownTimeout は終了時に監視用のHTTPサーバーの応答中のリクエストを待つ最大の時間
+const monitorShutdownTimeout = 5 * time.Second
+
+// gatewayState はDiscordのゲートウェイとの接続状態（ヘルスチェック用）
+// イベントハンドラーとHTTPサーバーの両方から読み書きするため、全てatomicで扱う
+type gatewayState struct {
+	connected atomic.Bool  // ゲートウェイに接続しているかどうか
+	ready     atomic.Bool  // Readyイベントを受け取り、メッセージを処理できる状態かどうか
+	changedAt atomic.Int64 // 接続状態が最後に変わった時刻（UnixNano）
+	lastEvent atomic.Int64 // 最後にイベントを受け取った時刻（UnixNano）
+}
+
+// healthResponse は /healthz と /readyz が返す内容
+type healthResponse struct {
+	Status   string             `json:"status"`   // ok または unavailable
+	Problems []string           `json:"problems"` // unavailableの理由
+	Gateway  gatewayHealth      `json:"gateway"`
+	Circuits []api.CircuitState `json:"circuits"` // 失敗が記録されている外部APIの接続先
+}
+
+// gatewayHealth はヘルスチェックで返すゲートウェイとの接続状態
+type gatewayHealth struct {
+	Connected             bool      `json:"connected"`
+	Ready                 bool      `json:"ready"`
+	StateChangedAt        time.Time `json:"state_changed_at,omitzero"`
+	LastHeartbeatAck      time.Time `json:"last_heartbeat_ack,omitzero"`
+	SecondsSinceHeartbeat float64   `json:"seconds_since_heartbeat_ack"`
+	LastEvent             time.Time `json:"last_event,omitzero"`
+	SecondsSinceLastEvent float64   `json:"seconds_since_last_event"`
+}
+
+// startMonitor は設定されたアドレスで監視用のHTTPサーバーを起動する（アドレスが空なら何もしない）
+// /metrics でPrometheus形式のメトリクスを、/healthz と /readyz でボットの状態を公開する
+func (b *KizunaBot) startMonitor() error {
+	addr := b.cfg().HTTPAddr
+	if addr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", b.handleHealthz)
+	mux.HandleFunc("GET /readyz", b.handleReadyz)
+
+	// 使用中のアドレスなどのエラーは起動時に気付けるよう、待ち受けだけ先に始めておく
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	b.monitor = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := b.monitor.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("監視用のHTTPサーバーが停止しました", "error", err)
+		}
+	}()
+
+	slog.Info("監視用のHTTPサーバーを起動しました", "addr", listener.Addr().String())
+	return nil
+}
+
+// stopMonitor は監視用のHTTPサーバーを停止する
+func (b *KizunaBot) stopMonitor() {
+	if b.monitor == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), monitorShutdownTimeout)
+	defer cancel()
+	if err := b.monitor.Shutdown(ctx); err != nil {
+		slog.Error("監視用のHTTPサーバーの停止に失敗しました", "error", err)
+	}
+}
+
+// handleHealthz はボットが動き続けているかを返す（失敗が続く場合は再起動が必要）
+// ゲートウェイとの接続が切れたまま、またはハートビートの応答がないまま health_timeout を過ぎると503を返す
+func (b *KizunaBot) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	health := b.health()
+	timeout := b.cfg().HealthTimeout
+
+	if !health.Gateway.Connected && time.Since(health.Gateway.StateChangedAt) > timeout {
+		health.Problems = append(health.Problems, "gateway disconnected for too long")
+	}
+	if health.Gateway.Connected && health.Gateway.SecondsSinceHeartbeat > timeout.Seconds() {
+		health.Problems = append(health.Problems, "no heartbeat ack from gateway")
+	}
+	writeHealth(w, health)
+}
+
+// handleReadyz はボットがメッセージを処理できる状態かを返す
+// ゲートウェイに接続していない時や、終了処理中は503を返す
+// 外部APIの不調はボットの再起動では直らないため、circuitsに表示するだけで失敗にはしない
+func (b *KizunaBot) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	health := b.health()
+
+	if !health.Gateway.Connected {
+		health.Problems = append(health.Problems, "gateway not connected")
+	} else if !health.Gateway.Ready {
+		health.Problems = append(health.Problems, "waiting for ready event")
+	}
+	if b.handlers.closing() {
+		health.Problems = append(health.Problems, "shutting down")
+	}
+	writeHealth(w, health)
+}
+
+// health は現在のゲートウェイと外部APIの状態を集める
+func (b *KizunaBot) health() *healthResponse {
+	now := time.Now()
+	gateway := gatewayHealth{
+		Connected:      b.gateway.connected.Load(),
+		Ready:          b.gateway.ready.Load(),
+		StateChangedAt: unixNanoTime(b.gateway.changedAt.Load()),
+		LastEvent:      unixNanoTime(b.gateway.lastEvent.Load()),
+	}
+	if b.session != nil {
+		b.session.RLock()
+		gateway.LastHeartbeatAck = b.session.LastHeartbeatAck
+		b.session.RUnlock()
+	}
+	if !gateway.LastHeartbeatAck.IsZero() {
+		gateway.SecondsSinceHeartbeat = now.Sub(gateway.LastHeartbeatAck).Seconds()
+	}
+	if !gateway.LastEvent.IsZero() {
+		gateway.SecondsSinceLastEvent = now.Sub(gateway.LastEvent).Seconds()
+	}
+
+	return &healthResponse{
+		Problems: []string{},
+		Gateway:  gateway,
+		Circuits: b.apiClient.CircuitStates(),
+	}
+}
+
+// writeHealth は問題がなければ200、あれば503でヘルスチェックの結果をJSONとして返す
+func writeHealth(w http.ResponseWriter, health *healthResponse) {
+	status := http.StatusOK
+	health.Status = "ok"
+	if len(health.Problems) > 0 {
+		status = http.StatusServiceUnavailable
+		health.Status = "unavailable"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(health); err != nil {
+		slog.Error("ヘルスチェックの応答に失敗しました", "error", err)
+	}
+}
+
+// unixNanoTime はUnixNanoの値を時刻に戻す（0ならゼロ値）
+func unixNanoTime(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// event は全てのゲートウェイイベントで呼ばれるイベントハンドラー（最後に受け取った時刻を記録する）
+func (b *KizunaBot) event(s *discordgo.Session, event *discordgo.Event) {
+	b.gateway.lastEvent.Store(time.Now().UnixNano())
+}
+
+// connect はDiscordのゲートウェイに接続した時に呼ばれるイベントハンドラー
+func (b *KizunaBot) connect(s *discordgo.Session, event *discordgo.Connect) {
+	b.gateway.connected.Store(true)
+	b.gateway.changedAt.Store(time.Now().UnixNano())
+	metrics.GatewayConnected.Set(1)
+}
+
+// resumed は切れた接続を再開できた時に呼ばれるイベントハンドラー
+// 再開の場合はReadyイベントが届かないため、ここでメッセージを処理できる状態に戻す
+func (b *KizunaBot) resumed(s *discordgo.Session, event *discordgo.Resumed) {
+	b.gateway.ready.Store(true)
+}
+
+// disconnect はDiscordのゲートウェイとの接続が切れた時に呼ばれるイベントハンドラー
+// discordgoが自動で再接続し、成功するとconnectが呼ばれる
+func (b *KizunaBot) disconnect(s *discordgo.Session, event *discordgo.Disconnect) {
+	b.gateway.connected.Store(false)
+	b.gateway.ready.Store(false)
+	b.gateway.changedAt.Store(time.Now().UnixNano())
+	metrics.GatewayConnected.Set(0)
+	slog.Warn("Discordのゲートウェイとの接続が切れました")
+}
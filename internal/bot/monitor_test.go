@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"kizuna_bot_go/internal/config"
+)
+
+// setGateway はゲートウェイとの接続状態を、changedAgo前に変わったものとして設定する
+func setGateway(b *KizunaBot, connected, ready bool, changedAgo time.Duration) {
+	b.gateway.connected.Store(connected)
+	b.gateway.ready.Store(ready)
+	b.gateway.changedAt.Store(time.Now().Add(-changedAgo).UnixNano())
+}
+
+// setHeartbeatAck はゲートウェイからのハートビートの応答をago前に受け取ったものとして設定する
+func setHeartbeatAck(t *testing.T, b *KizunaBot, ago time.Duration) {
+	b.session = &discordgo.Session{LastHeartbeatAck: time.Now().Add(-ago)}
+	// 接続していないセッションをCloseで閉じないようにする
+	t.Cleanup(func() { b.session = nil })
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	const timeout = time.Minute
+
+	tests := []struct {
+		name        string
+		setup       func(t *testing.T, b *KizunaBot)
+		wantHealthz int
+		wantReadyz  int
+		wantProblem string // どちらかのproblemsに含まれるべき理由
+	}{
+		{
+			name: "接続してメッセージを処理できる",
+			setup: func(t *testing.T, b *KizunaBot) {
+				setGateway(b, true, true, time.Hour)
+				setHeartbeatAck(t, b, time.Second)
+			},
+			wantHealthz: http.StatusOK,
+			wantReadyz:  http.StatusOK,
+		},
+		{
+			name: "切れたばかりなら再接続を待つ",
+			setup: func(t *testing.T, b *KizunaBot) {
+				setGateway(b, false, false, time.Second)
+			},
+			wantHealthz: http.StatusOK,
+			wantReadyz:  http.StatusServiceUnavailable,
+			wantProblem: "gateway not connected",
+		},
+		{
+			name: "health_timeoutより長く切れている",
+			setup: func(t *testing.T, b *KizunaBot) {
+				setGateway(b, false, false, 2*timeout)
+			},
+			wantHealthz: http.StatusServiceUnavailable,
+			wantReadyz:  http.StatusServiceUnavailable,
+			wantProblem: "gateway disconnected for too long",
+		},
+		{
+			name: "ハートビートの応答が途絶えている",
+			setup: func(t *testing.T, b *KizunaBot) {
+				setGateway(b, true, true, time.Hour)
+				setHeartbeatAck(t, b, 2*timeout)
+			},
+			wantHealthz: http.StatusServiceUnavailable,
+			wantReadyz:  http.StatusOK,
+			wantProblem: "no heartbeat ack from gateway",
+		},
+		{
+			name: "Readyイベントを待っている",
+			setup: func(t *testing.T, b *KizunaBot) {
+				setGateway(b, true, false, time.Second)
+			},
+			wantHealthz: http.StatusOK,
+			wantReadyz:  http.StatusServiceUnavailable,
+			wantProblem: "waiting for ready event",
+		},
+		{
+			name: "終了処理中",
+			setup: func(t *testing.T, b *KizunaBot) {
+				setGateway(b, true, true, time.Hour)
+				b.handlers.drain(time.Millisecond)
+			},
+			wantHealthz: http.StatusOK,
+			wantReadyz:  http.StatusServiceUnavailable,
+			wantProblem: "shutting down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBot(t, func(cfg *config.Config) { cfg.HealthTimeout = timeout })
+			tt.setup(t, b)
+
+			var problems []string
+			for _, endpoint := range []struct {
+				name    string
+				handler http.HandlerFunc
+				want    int
+			}{
+				{"/healthz", b.handleHealthz, tt.wantHealthz},
+				{"/readyz", b.handleReadyz, tt.wantReadyz},
+			} {
+				rec := httptest.NewRecorder()
+				endpoint.handler(rec, httptest.NewRequest(http.MethodGet, endpoint.name, nil))
+
+				var health healthResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+					t.Fatalf("%s: invalid JSON: %v", endpoint.name, err)
+				}
+				if rec.Code != endpoint.want {
+					t.Errorf("%s = %d, want %d (problems: %v)", endpoint.name, rec.Code, endpoint.want, health.Problems)
+				}
+				wantStatus := "ok"
+				if endpoint.want != http.StatusOK {
+					wantStatus = "unavailable"
+				}
+				if health.Status != wantStatus {
+					t.Errorf("%s status = %q, want %q", endpoint.name, health.Status, wantStatus)
+				}
+				problems = append(problems, health.Problems...)
+			}
+
+			if tt.wantProblem != "" && !slices.Contains(problems, tt.wantProblem) {
+				t.Errorf("problems = %v, want %q", problems, tt.wantProblem)
+			}
+		})
+	}
+}
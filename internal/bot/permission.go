@@ -0,0 +1,345 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"kizuna_bot_go/internal/store"
+)
+
+// permissionNames は /perm で指定できる権限名と、対応するDiscordの権限ビット
+var permissionNames = map[string]int64{
+	"administrator":        discordgo.PermissionAdministrator,
+	"manage_guild":         discordgo.PermissionManageGuild,
+	"manage_channels":      discordgo.PermissionManageChannels,
+	"manage_roles":         discordgo.PermissionManageRoles,
+	"manage_messages":      discordgo.PermissionManageMessages,
+	"kick_members":         discordgo.PermissionKickMembers,
+	"ban_members":          discordgo.PermissionBanMembers,
+	"moderate_members":     discordgo.PermissionModerateMembers,
+	"mention_everyone":     discordgo.PermissionMentionEveryone,
+	"embed_links":          discordgo.PermissionEmbedLinks,
+	"attach_files":         discordgo.PermissionAttachFiles,
+	"read_message_history": discordgo.PermissionReadMessageHistory,
+}
+
+// permActions は /perm で指定できる変更の種類
+var permActions = []string{
+	"require_permission", "add_role", "remove_role", "allow_user", "deny_user",
+	"remove_user", "add_channel", "remove_channel", "clear",
+}
+
+// snowflakePattern はメンション（<@123>、<@&123>、<#123>）や数字だけの文字列からIDを取り出す
+var snowflakePattern = regexp.MustCompile(`^<?[@#]?[!&]?(\d+)>?$`)
+
+// CommandRule はギルド内で1つのコマンドを使えるユーザーを制限するルール
+// 管理者はルールに関係なく全てのコマンドを使える
+type CommandRule struct {
+	Permissions int64    `json:"permissions,omitempty"` // 必要な権限（全て持っている必要がある）
+	Roles       []string `json:"roles,omitempty"`       // いずれかを持っていれば使えるロールのID
+	AllowUsers  []string `json:"allow_users,omitempty"` // ロールや権限に関係なく使えるユーザーのID
+	DenyUsers   []string `json:"deny_users,omitempty"`  // 使えないユーザーのID
+	Channels    []string `json:"channels,omitempty"`    // 使えるチャンネルのID（空なら全てのチャンネル）
+}
+
+// isEmpty はルールに何も設定されていないかどうかを返す
+func (r *CommandRule) isEmpty() bool {
+	return r.Permissions == 0 && len(r.Roles) == 0 && len(r.AllowUsers) == 0 &&
+		len(r.DenyUsers) == 0 && len(r.Channels) == 0
+}
+
+// checkCommandRule はコマンドのルールを確認し、実行できない場合はその理由（ユーザーへの返答）を返す
+// 実行できる場合は空文字列を返す
+func checkCommandRule(ctx *CommandContext, cmd Command) string {
+	rule, ok := ctx.Guild.CommandRules[cmd.Name()]
+	if !ok || ctx.GuildID == "" {
+		return ""
+	}
+
+	// 管理者は設定を直せなくならないよう、常に使える
+	if ctx.IsAdmin() {
+		return ""
+	}
+
+	name := ctx.Guild.CommandPrefix() + cmd.Name()
+	userID := ctx.Author.ID
+
+	if slices.Contains(rule.DenyUsers, userID) {
+		return fmt.Sprintf("ごめんね、%s は使わないでって言われてるの……", name)
+	}
+
+	if len(rule.Channels) > 0 && !slices.Contains(rule.Channels, ctx.ChannelID) {
+		var mentions []string
+		for _, channelID := range rule.Channels {
+			mentions = append(mentions, fmt.Sprintf("<#%s>", channelID))
+		}
+		return fmt.Sprintf("%s はこのチャンネルでは使えないよ、%s で使ってね！", name, strings.Join(mentions, " "))
+	}
+
+	// 許可リストに入っているユーザーはロールや権限の条件を満たさなくても使える
+	if slices.Contains(rule.AllowUsers, userID) {
+		return ""
+	}
+
+	if len(rule.AllowUsers) > 0 || len(rule.Roles) > 0 {
+		hasRole := false
+		if ctx.Member != nil {
+			hasRole = slices.ContainsFunc(ctx.Member.Roles, func(role string) bool {
+				return slices.Contains(rule.Roles, role)
+			})
+		}
+		if !hasRole {
+			return fmt.Sprintf("ごめんね、%s は決められた人しか使えないんだ", name)
+		}
+	}
+
+	if rule.Permissions != 0 {
+		perms, err := ctx.Permissions()
+		if err != nil {
+			ctx.Logger().Error("権限の取得に失敗しました", "error", err)
+		}
+		if err != nil || perms&rule.Permissions != rule.Permissions {
+			return fmt.Sprintf("ごめんね、%s を使うには %s の権限が必要なんだ", name, formatPermissions(rule.Permissions))
+		}
+	}
+
+	return ""
+}
+
+// handlePerm はコマンドごとの利用ルールを表示・変更する（管理者専用）
+func (b *KizunaBot) handlePerm(ctx *CommandContext) {
+	prefix := ctx.Guild.CommandPrefix()
+	if ctx.GuildID == "" {
+		ctx.Reply(prefix + "perm はサーバーの中で使ってね！")
+		return
+	}
+	if !ctx.IsAdmin() {
+		ctx.Reply(fmt.Sprintf("ごめんね、%sperm はサーバーの管理者さんしか使えないんだ", prefix))
+		return
+	}
+
+	// 引数がなければルールが設定されているコマンドの一覧を表示
+	if len(ctx.Args) == 0 {
+		ctx.Reply(formatCommandRules(ctx.Guild))
+		return
+	}
+
+	cmd, ok := b.commands.Find(strings.TrimPrefix(strings.TrimPrefix(ctx.Args[0], prefix), "/"))
+	if !ok {
+		ctx.Reply(fmt.Sprintf("「%s」というコマンドは知らないなあ……", ctx.Args[0]))
+		return
+	}
+	name := cmd.Name()
+
+	rule := ctx.Guild.CommandRules[name]
+	if rule == nil {
+		rule = &CommandRule{}
+	}
+
+	// コマンド名だけの場合はそのコマンドのルールを表示
+	if len(ctx.Args) == 1 {
+		ctx.Reply(fmt.Sprintf("%s%s のルールだよ！\n%s", prefix, name, formatCommandRule(rule)))
+		return
+	}
+
+	action := strings.ToLower(ctx.Args[1])
+	if !slices.Contains(permActions, action) {
+		ctx.Reply(fmt.Sprintf("変更できるのは %s だよ！", strings.Join(permActions, ", ")))
+		return
+	}
+
+	value := ""
+	if len(ctx.Args) > 2 {
+		value = strings.TrimSpace(strings.Join(ctx.Args[2:], " "))
+	}
+
+	// clear と require_permission 以外は値としてIDかメンションが必要
+	id := ""
+	if action != "clear" && action != "require_permission" {
+		match := snowflakePattern.FindStringSubmatch(value)
+		if match == nil {
+			ctx.Reply("ロール・ユーザー・チャンネルはメンションかIDで指定してね！ 例: " + prefix + "perm image add_role @メンバー")
+			return
+		}
+		id = match[1]
+	}
+
+	// メッセージを受け取った後に他の変更が保存されていても上書きしないよう、ロックを取得してから読み込み直す
+	b.guildSettingsMu.Lock()
+	defer b.guildSettingsMu.Unlock()
+
+	settings, err := b.loadGuildSettings(ctx.GuildID)
+	if err != nil {
+		ctx.Logger().Error("ギルド設定の読み込みに失敗しました", "error", err)
+		ctx.Reply("設定の読み込みに失敗しました。しばらく時間をおいてからお試しください。")
+		return
+	}
+	rule = settings.CommandRules[name]
+	if rule == nil {
+		rule = &CommandRule{}
+	}
+
+	switch action {
+	case "require_permission":
+		perms, err := parsePermissions(value)
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("権限の名前がわからないよ……使えるのは none, %s だよ", strings.Join(sortedPermissionNames(), ", ")))
+			return
+		}
+		rule.Permissions = perms
+	case "add_role":
+		rule.Roles = appendUnique(rule.Roles, id)
+	case "remove_role":
+		rule.Roles = removeValue(rule.Roles, id)
+	case "allow_user":
+		rule.AllowUsers = appendUnique(rule.AllowUsers, id)
+		rule.DenyUsers = removeValue(rule.DenyUsers, id)
+	case "deny_user":
+		rule.DenyUsers = appendUnique(rule.DenyUsers, id)
+		rule.AllowUsers = removeValue(rule.AllowUsers, id)
+	case "remove_user":
+		rule.AllowUsers = removeValue(rule.AllowUsers, id)
+		rule.DenyUsers = removeValue(rule.DenyUsers, id)
+	case "add_channel":
+		rule.Channels = appendUnique(rule.Channels, id)
+	case "remove_channel":
+		rule.Channels = removeValue(rule.Channels, id)
+	case "clear":
+		rule = &CommandRule{}
+	}
+
+	if rule.isEmpty() {
+		delete(settings.CommandRules, name)
+	} else {
+		if settings.CommandRules == nil {
+			settings.CommandRules = make(map[string]*CommandRule)
+		}
+		settings.CommandRules[name] = rule
+	}
+
+	if err := b.store.Put(store.BucketGuildSettings, ctx.GuildID, settings); err != nil {
+		ctx.Logger().Error("コマンドのルールの保存に失敗しました", "error", err)
+		ctx.Reply("設定の保存に失敗しました。しばらく時間をおいてからお試しください。")
+		return
+	}
+	ctx.Reply(fmt.Sprintf("%s%s のルールを変更したよ！\n%s", prefix, name, formatCommandRule(rule)))
+}
+
+// formatCommandRules はルールが設定されている全てのコマンドを表示用の文字列にする
+func formatCommandRules(settings *GuildSettings) string {
+	if len(settings.CommandRules) == 0 {
+		return "今はどのコマンドも誰でも使えるよ！"
+	}
+
+	var names []string
+	for name := range settings.CommandRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	message := "ルールが決まっているコマンドだよ！"
+	for _, name := range names {
+		message += fmt.Sprintf("\n%s%s\n%s", settings.CommandPrefix(), name, formatCommandRule(settings.CommandRules[name]))
+	}
+	return message
+}
+
+// formatCommandRule は1つのコマンドのルールを表示用の文字列にする
+func formatCommandRule(rule *CommandRule) string {
+	if rule.isEmpty() {
+		return "  誰でも使えるよ"
+	}
+
+	mentions := func(format string, ids []string) string {
+		var parts []string
+		for _, id := range ids {
+			parts = append(parts, fmt.Sprintf(format, id))
+		}
+		return strings.Join(parts, " ")
+	}
+
+	var lines []string
+	if rule.Permissions != 0 {
+		lines = append(lines, "  必要な権限: "+formatPermissions(rule.Permissions))
+	}
+	if len(rule.Roles) > 0 {
+		lines = append(lines, "  使えるロール: "+mentions("<@&%s>", rule.Roles))
+	}
+	if len(rule.AllowUsers) > 0 {
+		lines = append(lines, "  使える人: "+mentions("<@%s>", rule.AllowUsers))
+	}
+	if len(rule.DenyUsers) > 0 {
+		lines = append(lines, "  使えない人: "+mentions("<@%s>", rule.DenyUsers))
+	}
+	if len(rule.Channels) > 0 {
+		lines = append(lines, "  使えるチャンネル: "+mentions("<#%s>", rule.Channels))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parsePermissions は「manage_messages,kick_members」のような権限名の並び（または数値）を権限ビットに変換
+// 「none」や空文字列の場合は0（権限不要）を返す
+func parsePermissions(value string) (int64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "none" {
+		return 0, nil
+	}
+	if perms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return perms, nil
+	}
+
+	var perms int64
+	for _, name := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '、' }) {
+		bit, ok := permissionNames[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown permission: %s", name)
+		}
+		perms |= bit
+	}
+	return perms, nil
+}
+
+// formatPermissions は権限ビットを「manage_messages, kick_members」のような権限名の並びにする
+func formatPermissions(perms int64) string {
+	var names []string
+	remaining := perms
+	for _, name := range sortedPermissionNames() {
+		if bit := permissionNames[name]; perms&bit == bit {
+			names = append(names, name)
+			remaining &^= bit
+		}
+	}
+	// 名前の付いていない権限は数値のまま表示
+	if remaining != 0 {
+		names = append(names, strconv.FormatInt(remaining, 10))
+	}
+	return strings.Join(names, ", ")
+}
+
+// sortedPermissionNames は指定できる権限名をアルファベット順に返す
+func sortedPermissionNames() []string {
+	var names []string
+	for name := range permissionNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// appendUnique はまだ含まれていない場合だけ値を追加する
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// removeValue は値を全て取り除く
+func removeValue(values []string, value string) []string {
+	return slices.DeleteFunc(values, func(v string) bool { return v == value })
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestHandlePermKeepsConcurrentChanges(t *testing.T) {
+	b, fake := newTestBot(t)
+
+	// imageのルールを変更するメッセージを受け取った後、処理する前にpingのルールとプレフィックスが変わった
+	stale := b.guildSettings(testGuildID)
+	post(b, fake, testAdmin, "/perm ping deny_user 111111111111111111")
+	post(b, fake, testAdmin, "/config prefix !")
+	b.handlePerm(staleCommand(fake, stale, "image", "add_role", "222222222222222222"))
+
+	settings := b.guildSettings(testGuildID)
+	if settings.Prefix != "!" {
+		t.Errorf("Prefix = %q, lost the concurrent /config change", settings.Prefix)
+	}
+	if rule := settings.CommandRules["ping"]; rule == nil || !slices.Contains(rule.DenyUsers, "111111111111111111") {
+		t.Errorf("ping rule = %+v, lost the concurrent /perm change", rule)
+	}
+	if rule := settings.CommandRules["image"]; rule == nil || !slices.Contains(rule.Roles, "222222222222222222") {
+		t.Errorf("image rule = %+v, want the role added", rule)
+	}
+}
+
+func TestHandlePermSameCommand(t *testing.T) {
+	b, fake := newTestBot(t)
+
+	// 同じコマンドのルールも、読み込み直した最新のルールに追加する
+	stale := b.guildSettings(testGuildID)
+	post(b, fake, testAdmin, "/perm ping deny_user 111111111111111111")
+	b.handlePerm(staleCommand(fake, stale, "ping", "add_channel", "333333333333333333"))
+
+	rule := b.guildSettings(testGuildID).CommandRules["ping"]
+	if rule == nil || !slices.Contains(rule.DenyUsers, "111111111111111111") || !slices.Contains(rule.Channels, "333333333333333333") {
+		t.Errorf("ping rule = %+v, want both changes", rule)
+	}
+}
+
+func TestHandlePermDeniesUser(t *testing.T) {
+	b, fake := newTestBot(t)
+	// /perm ではユーザーをメンションかIDで指定するため、数字のIDを持つユーザーを使う
+	member := &discordgo.User{ID: "444444444444444444", Username: "member"}
+
+	sent := post(b, fake, testAdmin, "/perm ping deny_user <@"+member.ID+">")
+	if len(sent) != 1 || !strings.Contains(sent[0].Content, "/ping のルールを変更したよ！") {
+		t.Fatalf("reply to /perm = %v", sent)
+	}
+
+	sent = post(b, fake, member, "/ping")
+	if len(sent) != 1 || strings.HasPrefix(sent[0].Content, "Pong！") {
+		t.Errorf("denied user could use /ping: %v", sent)
+	}
+
+	post(b, fake, testAdmin, "/perm ping clear")
+	sent = post(b, fake, member, "/ping")
+	if len(sent) != 1 || !strings.HasPrefix(sent[0].Content, "Pong！") {
+		t.Errorf("reply to /ping after clear = %v", sent)
+	}
+}
@@ -0,0 +1,246 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"kizuna_bot_go/internal/api"
+	"kizuna_bot_go/internal/logging"
+	"kizuna_bot_go/internal/store"
+)
+
+// rainAlertDays は雨のお知らせで確かめる予報の日数（今日と明日）
+const rainAlertDays = 2
+
+// /weather alert の最初の引数で指定できる操作（それ以外は地名として扱う）
+const (
+	rainAlertOff   = "off"   // お知らせをやめる（管理者のみ）
+	rainAlertJoin  = "join"  // お知らせの時にメンションしてもらう
+	rainAlertLeave = "leave" // メンションしてもらうのをやめる
+)
+
+// RainAlertSubscription は1つのチャンネルへの雨のお知らせの設定と、最後に確かめた天気予報
+type RainAlertSubscription struct {
+	ChannelID string                      `json:"channel_id"`          // お知らせするチャンネル
+	GuildID   string                      `json:"guild_id"`            // チャンネルのあるギルド
+	CityID    int                         `json:"city_id"`             // 天気予報の都市ID
+	Mentions  []string                    `json:"mentions,omitempty"`  // お知らせの時にメンションするユーザーのID
+	LastSeen  map[string]ForecastSnapshot `json:"last_seen,omitempty"` // 日付（2006-01-02）ごとの最後に確かめた予報（nilなら登録してからまだ確かめていない）
+}
+
+// ForecastSnapshot は雨かどうかの判断に使う、1日分の天気予報の要点
+type ForecastSnapshot struct {
+	Telop        string `json:"telop"`          // 「曇のち雨」のような天気
+	ChanceOfRain int    `json:"chance_of_rain"` // 最も高い降水確率（発表されていない場合は0）
+}
+
+// snapshotForecast は1日分の天気予報から要点を取り出す
+func snapshotForecast(forecast api.WeatherForecast) ForecastSnapshot {
+	percent, _ := forecast.ChanceOfRain.Max()
+	return ForecastSnapshot{Telop: forecast.Telop, ChanceOfRain: percent}
+}
+
+// rainy は天気に雨か雪が含まれるか、降水確率がthreshold（%）以上ならtrueを返す
+func (s ForecastSnapshot) rainy(threshold int) bool {
+	return strings.ContainsAny(s.Telop, "雨雪") || s.ChanceOfRain >= threshold
+}
+
+// checkRainAlerts は雨のお知らせが登録された全てのチャンネルについて、予報が雨に変わっていないか確かめる
+func (b *KizunaBot) checkRainAlerts() {
+	var subscriptions []*RainAlertSubscription
+	err := b.store.ForEach(store.BucketRainAlerts, func(key string, decode func(v any) error) error {
+		subscription := &RainAlertSubscription{}
+		if err := decode(subscription); err != nil {
+			slog.Error("雨のお知らせの設定の読み込みに失敗しました", "channel_id", key, "error", err)
+			return nil
+		}
+		subscriptions = append(subscriptions, subscription)
+		return nil
+	})
+	if err != nil {
+		slog.Error("雨のお知らせの設定の読み込みに失敗しました", "error", err)
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		b.checkRainAlert(subscription)
+	}
+}
+
+// checkRainAlert は1つのチャンネルについて、今日か明日の予報が前回から雨に変わっていればお知らせし、今回の予報を記録する
+// 登録してから最初の確認では記録だけを行う。お知らせに失敗した場合は記録せず、次の確認の時にもう一度試す
+func (b *KizunaBot) checkRainAlert(subscription *RainAlertSubscription) {
+	ctx := logging.With(b.ctx, "correlation_id", logging.NewCorrelationID(), "guild_id", subscription.GuildID, "channel_id", subscription.ChannelID)
+	logger := logging.FromContext(ctx)
+
+	response, err := b.apiClient.GetForecastContext(ctx, subscription.CityID)
+	if err != nil {
+		logger.Log(ctx, apiErrorLevel(err), "雨のお知らせの天気情報の取得に失敗しました", "error", err)
+		return
+	}
+
+	threshold := b.cfg().RainAlert.Threshold
+	seen := make(map[string]ForecastSnapshot)
+	var turnedRainy []api.WeatherForecast
+	for _, forecast := range response.Forecasts[:min(rainAlertDays, len(response.Forecasts))] {
+		snapshot := snapshotForecast(forecast)
+		seen[forecast.Date] = snapshot
+		// 前回の確認の時に予報がまだ出ていなかった日は、雨ではなかったものとして扱う
+		if subscription.LastSeen != nil && !subscription.LastSeen[forecast.Date].rainy(threshold) && snapshot.rainy(threshold) {
+			turnedRainy = append(turnedRainy, forecast)
+		}
+	}
+
+	if len(turnedRainy) > 0 {
+		if _, err := b.messenger.Send(subscription.ChannelID, rainAlertMessage(response, turnedRainy, subscription.Mentions)); err != nil {
+			logger.Warn("雨のお知らせの送信に失敗しました", "error", err)
+			return
+		}
+		logger.Info("雨のお知らせを送信しました", "city_id", subscription.CityID, "days", len(turnedRainy))
+	}
+
+	// 確かめている間に設定が変わっていても上書きしないよう、読み込み直してから予報だけを記録する
+	b.rainAlertMu.Lock()
+	defer b.rainAlertMu.Unlock()
+
+	current := &RainAlertSubscription{}
+	found, err := b.store.Get(store.BucketRainAlerts, subscription.ChannelID, current)
+	if err != nil {
+		logger.Error("雨のお知らせの設定の読み込みに失敗しました", "error", err)
+		return
+	}
+	if !found || current.CityID != subscription.CityID {
+		return
+	}
+	current.LastSeen = seen
+	if err := b.store.Put(store.BucketRainAlerts, current.ChannelID, current); err != nil {
+		logger.Error("雨のお知らせの予報の記録に失敗しました", "error", err)
+	}
+}
+
+// rainAlertMessage は雨に変わった日の予報をお知らせするメッセージを作成
+func rainAlertMessage(response *api.WeatherResponse, forecasts []api.WeatherForecast, mentions []string) string {
+	message := ""
+	if len(mentions) > 0 {
+		users := make([]string, len(mentions))
+		for i, userID := range mentions {
+			users[i] = fmt.Sprintf("<@%s>", userID)
+		}
+		message += strings.Join(users, " ") + "\n"
+	}
+
+	message += "雨のお知らせだよ :umbrella:\n"
+	for _, forecast := range forecasts {
+		message += fmt.Sprintf("%s（%s）の%sの天気は「%s」", forecast.DateLabel, forecast.Date, response.Location.City, forecast.Telop)
+		if percent, ok := forecast.ChanceOfRain.Max(); ok {
+			message += fmt.Sprintf("、降水確率は最大%d%%", percent)
+		}
+		message += "になりそう\n"
+	}
+	return message + "お出かけの時は傘を忘れないでね！"
+}
+
+// handleRainAlert はチャンネルへの雨のお知らせを表示・設定する
+// 引数は「大阪」のような地名か、お知らせをやめる「off」（どちらも管理者のみ）、
+// またはお知らせの時にメンションしてもらう「join」とやめる「leave」
+func (b *KizunaBot) handleRainAlert(ctx *CommandContext, args []string) {
+	prefix := ctx.Guild.CommandPrefix()
+	if ctx.GuildID == "" {
+		ctx.Reply(fmt.Sprintf("%sweather alert はサーバーの中で使ってね！", prefix))
+		return
+	}
+
+	// 定期的な確認の結果の保存と、設定の変更が上書きし合わないようにする
+	b.rainAlertMu.Lock()
+	defer b.rainAlertMu.Unlock()
+
+	subscription := &RainAlertSubscription{}
+	found, err := b.store.Get(store.BucketRainAlerts, ctx.ChannelID, subscription)
+	if err != nil {
+		ctx.Logger().Error("雨のお知らせの設定の読み込みに失敗しました", "error", err)
+		ctx.Reply("設定の読み込みに失敗しました。しばらく時間をおいてからお試しください。")
+		return
+	}
+
+	// 引数がなければ現在の設定を表示
+	if len(args) == 0 {
+		if !found {
+			ctx.Reply(fmt.Sprintf("このチャンネルでは雨のお知らせをしていないよ！ 「%sweather alert 東京」みたいに設定してね", prefix))
+			return
+		}
+		ctx.Reply(fmt.Sprintf("このチャンネルでは、%sの今日と明日の天気予報が雨になりそうな時（降水確率%d%%以上も含むよ）にお知らせしているよ！ メンションするのは%d人だよ",
+			cityLabel(subscription.CityID), b.cfg().RainAlert.Threshold, len(subscription.Mentions)))
+		return
+	}
+
+	action := strings.ToLower(args[0])
+	if action == rainAlertJoin || action == rainAlertLeave {
+		if !found {
+			ctx.Reply("このチャンネルでは雨のお知らせをしていないよ！ 管理者さんに設定してもらってね")
+			return
+		}
+		joined := slices.Contains(subscription.Mentions, ctx.Author.ID)
+		switch {
+		case action == rainAlertJoin && joined:
+			ctx.Reply("もう登録してあるよ！ 雨になりそうな時はメンションするね")
+			return
+		case action == rainAlertLeave && !joined:
+			ctx.Reply("メンションの登録はしていないみたい")
+			return
+		case action == rainAlertJoin:
+			subscription.Mentions = append(subscription.Mentions, ctx.Author.ID)
+		default:
+			subscription.Mentions = slices.DeleteFunc(subscription.Mentions, func(userID string) bool { return userID == ctx.Author.ID })
+		}
+		if err := b.store.Put(store.BucketRainAlerts, ctx.ChannelID, subscription); err != nil {
+			ctx.Logger().Error("雨のお知らせの設定の保存に失敗しました", "error", err)
+			ctx.Reply("設定の保存に失敗しました。しばらく時間をおいてからお試しください。")
+			return
+		}
+		if action == rainAlertJoin {
+			ctx.Reply("雨になりそうな時は、メンションしてお知らせするね！ :umbrella:")
+		} else {
+			ctx.Reply("メンションするのをやめたよ")
+		}
+		return
+	}
+
+	if !ctx.IsAdmin() {
+		ctx.Reply(fmt.Sprintf("ごめんね、%sweather alert の設定はサーバーの管理者さんしかできないんだ（メンションしてほしい時は「%sweather alert join」だよ）", prefix, prefix))
+		return
+	}
+
+	if action == rainAlertOff {
+		if err := b.store.Delete(store.BucketRainAlerts, ctx.ChannelID); err != nil {
+			ctx.Logger().Error("雨のお知らせの設定の削除に失敗しました", "error", err)
+			ctx.Reply("設定の保存に失敗しました。しばらく時間をおいてからお試しください。")
+			return
+		}
+		ctx.Reply("このチャンネルへの雨のお知らせをやめたよ")
+		return
+	}
+
+	place := strings.Join(args, " ")
+	city, suggestions, ok := findCity(place)
+	if !ok {
+		ctx.Reply(cityNotFoundMessage(prefix, place, suggestions))
+		return
+	}
+
+	// 地域を変えた時もメンションの登録は引き継ぎ、予報は次の確認の時に記録し直す
+	subscription = &RainAlertSubscription{
+		ChannelID: ctx.ChannelID,
+		GuildID:   ctx.GuildID,
+		CityID:    city.ID,
+		Mentions:  subscription.Mentions,
+	}
+	if err := b.store.Put(store.BucketRainAlerts, ctx.ChannelID, subscription); err != nil {
+		ctx.Logger().Error("雨のお知らせの設定の保存に失敗しました", "error", err)
+		ctx.Reply("設定の保存に失敗しました。しばらく時間をおいてからお試しください。")
+		return
+	}
+	ctx.Reply(fmt.Sprintf("%sの天気予報が雨に変わったら、このチャンネルでお知らせするね！ メンションしてほしい人は「%sweather alert join」してね :umbrella:",
+		cityLabel(city.ID), prefix))
+}
@@ -0,0 +1,210 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"kizuna_bot_go/internal/api"
+	"kizuna_bot_go/internal/messenger"
+	"kizuna_bot_go/internal/store"
+)
+
+func TestForecastSnapshotRainy(t *testing.T) {
+	tests := []struct {
+		telop  string
+		chance int
+		want   bool
+	}{
+		{telop: "晴れ", chance: 10, want: false},
+		{telop: "曇のち雨", chance: 0, want: true},
+		{telop: "雪", chance: 0, want: true},
+		{telop: "曇り", chance: 50, want: true},
+		{telop: "曇り", chance: 49, want: false},
+		{telop: "", chance: 0, want: false}, // 前回の予報がない日
+	}
+
+	for _, tt := range tests {
+		snapshot := ForecastSnapshot{Telop: tt.telop, ChanceOfRain: tt.chance}
+		if got := snapshot.rainy(50); got != tt.want {
+			t.Errorf("%+v.rainy(50) = %v, want %v", snapshot, got, tt.want)
+		}
+	}
+}
+
+// rainAlertTest はチャンネルテスト用のチャンネルに東京の雨のお知らせを登録したボット
+type rainAlertTest struct {
+	*testing.T
+	b      *KizunaBot
+	fake   *messenger.Fake
+	server *forecastServer
+}
+
+func newRainAlertTest(t *testing.T) *rainAlertTest {
+	t.Helper()
+
+	server, useServer := newForecastServer(t)
+	b, fake := newTestBot(t, useServer)
+	subscription := &RainAlertSubscription{ChannelID: testChannelID, GuildID: testGuildID, CityID: 130010, Mentions: []string{"u1", "u2"}}
+	if err := b.store.Put(store.BucketRainAlerts, testChannelID, subscription); err != nil {
+		t.Fatal(err)
+	}
+	return &rainAlertTest{T: t, b: b, fake: fake, server: server}
+}
+
+// check は予報を差し替えてから雨のお知らせを確かめ、送信したメッセージを返す
+func (rt *rainAlertTest) check(forecasts ...string) []string {
+	rt.Helper()
+
+	var days []apiForecast
+	for _, f := range forecasts {
+		days = append(days, testForecasts[f])
+	}
+	rt.server.setForecasts(days...)
+
+	before := len(rt.fake.Sent())
+	rt.b.checkRainAlerts()
+	var sent []string
+	for _, msg := range rt.fake.Sent()[before:] {
+		sent = append(sent, msg.Content)
+	}
+	return sent
+}
+
+// saved は保存されている雨のお知らせの設定を返す
+func (rt *rainAlertTest) saved() *RainAlertSubscription {
+	rt.Helper()
+
+	subscription := &RainAlertSubscription{}
+	if _, err := rt.b.store.Get(store.BucketRainAlerts, testChannelID, subscription); err != nil {
+		rt.Fatal(err)
+	}
+	return subscription
+}
+
+// testForecasts はテストで使う予報（今日は10月18日）
+var testForecasts = map[string]apiForecast{
+	"today sunny":      forecast("2026-10-18", "今日", "晴れ", "--%", "0%", "10%", "20%"),
+	"today rain":       forecast("2026-10-18", "今日", "雨", "--%", "60%", "80%", "50%"),
+	"tomorrow sunny":   forecast("2026-10-19", "明日", "晴れ", "0%", "0%", "10%", "10%"),
+	"tomorrow rain":    forecast("2026-10-19", "明日", "曇のち雨", "10%", "30%", "80%", "60%"),
+	"tomorrow chance":  forecast("2026-10-19", "明日", "曇り", "10%", "50%", "20%", "10%"),
+	"day after rain":   forecast("2026-10-20", "明後日", "雨", "80%", "80%", "80%", "80%"),
+	"next day rain":    forecast("2026-10-20", "明日", "雨", "80%", "80%", "80%", "80%"),
+	"next today sunny": forecast("2026-10-19", "今日", "晴れ", "0%", "0%", "10%", "10%"),
+}
+
+func TestCheckRainAlertFirstCheckOnlyRecords(t *testing.T) {
+	rt := newRainAlertTest(t)
+
+	if sent := rt.check("today rain", "tomorrow rain"); len(sent) != 0 {
+		t.Errorf("first check sent %q, want nothing", sent)
+	}
+	seen := rt.saved().LastSeen
+	if len(seen) != 2 || seen["2026-10-18"].Telop != "雨" || seen["2026-10-19"].ChanceOfRain != 80 {
+		t.Errorf("LastSeen = %+v, want today and tomorrow recorded", seen)
+	}
+
+	// 雨のままなら知らせない
+	if sent := rt.check("today rain", "tomorrow rain"); len(sent) != 0 {
+		t.Errorf("unchanged rain sent %q", sent)
+	}
+}
+
+func TestCheckRainAlertTurnsRainy(t *testing.T) {
+	rt := newRainAlertTest(t)
+	rt.check("today sunny", "tomorrow sunny")
+
+	sent := rt.check("today sunny", "tomorrow rain")
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	for _, want := range []string{"<@u1> <@u2>", "明日（2026-10-19）の東京の天気は「曇のち雨」、降水確率は最大80%"} {
+		if !strings.Contains(sent[0], want) {
+			t.Errorf("message does not contain %q:\n%s", want, sent[0])
+		}
+	}
+	if strings.Contains(sent[0], "今日") {
+		t.Errorf("message mentions a day that did not change:\n%s", sent[0])
+	}
+
+	// 降水確率がしきい値（50%）に届いた場合も知らせる
+	rt.check("today sunny", "tomorrow sunny")
+	if sent := rt.check("today sunny", "tomorrow chance"); len(sent) != 1 {
+		t.Errorf("chance of rain at the threshold sent %d messages, want 1", len(sent))
+	}
+
+	// 明後日の予報は見ない
+	if sent := rt.check("today sunny", "tomorrow chance", "day after rain"); len(sent) != 0 {
+		t.Errorf("day after tomorrow sent %q", sent)
+	}
+}
+
+func TestCheckRainAlertMissingDayCountsAsDry(t *testing.T) {
+	rt := newRainAlertTest(t)
+	rt.check("today sunny", "tomorrow sunny")
+
+	// 日付が変わり、前回は予報のなかった日が「明日」として雨で出てきた
+	sent := rt.check("next today sunny", "next day rain")
+	if len(sent) != 1 || !strings.Contains(sent[0], "明日（2026-10-20）") {
+		t.Errorf("sent %q, want an alert for the newly published rainy day", sent)
+	}
+
+	// 確かめた日だけを覚えておく（過ぎた日は忘れる）
+	seen := rt.saved().LastSeen
+	if _, ok := seen["2026-10-18"]; ok || len(seen) != 2 {
+		t.Errorf("LastSeen = %+v, want only 2026-10-19 and 2026-10-20", seen)
+	}
+}
+
+func TestCheckRainAlertSendFailureIsRetried(t *testing.T) {
+	rt := newRainAlertTest(t)
+	rt.check("today sunny", "tomorrow sunny")
+
+	rt.fake.SetSendError(errors.New("discord is down"))
+	rt.check("today sunny", "tomorrow rain")
+	if got := rt.saved().LastSeen["2026-10-19"].Telop; got != "晴れ" {
+		t.Errorf("recorded %q after a failed send, want the previous forecast kept", got)
+	}
+
+	// 送れるようになったら改めて知らせる
+	rt.fake.SetSendError(nil)
+	if sent := rt.check("today sunny", "tomorrow rain"); len(sent) != 1 {
+		t.Errorf("sent %d messages after recovering, want 1", len(sent))
+	}
+}
+
+func TestRainAlertCityChangeResetsState(t *testing.T) {
+	rt := newRainAlertTest(t)
+	rt.check("today sunny", "tomorrow sunny")
+
+	// 地域を変えると、メンションの登録は残したまま予報を記録し直す
+	post(rt.b, rt.fake, testAdmin, "/weather alert 大阪")
+	subscription := rt.saved()
+	if subscription.CityID != 270000 || subscription.LastSeen != nil || len(subscription.Mentions) != 2 {
+		t.Fatalf("subscription after changing the city = %+v", subscription)
+	}
+	if sent := rt.check("today rain", "tomorrow rain"); len(sent) != 0 {
+		t.Errorf("first check for the new city sent %q, want nothing", sent)
+	}
+}
+
+func TestCheckRainAlertDoesNotOverwriteCityChangedDuringCheck(t *testing.T) {
+	rt := newRainAlertTest(t)
+	rt.check("today sunny", "tomorrow sunny")
+
+	// 予報を取得している間に、管理者が地域を変える
+	rt.server.setOnRequest(func() {
+		rt.b.rainAlertMu.Lock()
+		defer rt.b.rainAlertMu.Unlock()
+		rt.b.store.Put(store.BucketRainAlerts, testChannelID, &RainAlertSubscription{ChannelID: testChannelID, GuildID: testGuildID, CityID: 270000})
+	})
+	rt.check("today sunny", "tomorrow rain")
+
+	if subscription := rt.saved(); subscription.CityID != 270000 || subscription.LastSeen != nil {
+		t.Errorf("subscription = %+v, want the new city kept without the old city's forecast", subscription)
+	}
+}
+
+// apiForecast はテストの予報の表を短く書くための別名
+type apiForecast = api.WeatherForecast
@@ -0,0 +1,261 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"kizuna_bot_go/internal/api"
+	"kizuna_bot_go/internal/logging"
+	"kizuna_bot_go/internal/store"
+)
+
+const (
+	// scheduleInterval は定期配信の時刻になったかを確かめる間隔
+	scheduleInterval = 30 * time.Second
+	// scheduleCatchUp は配信の時刻を過ぎてから、遅れて配信してよい時間
+	// 時刻ちょうどに停止や切断をしていても、この時間内に戻れば配信する
+	scheduleCatchUp = time.Hour
+	// scheduleOff は /weather schedule で定期配信をやめる時の引数
+	scheduleOff = "off"
+)
+
+// WeatherSchedule は1つのチャンネルへの天気予報の定期配信の設定
+type WeatherSchedule struct {
+	ChannelID  string `json:"channel_id"`            // 配信するチャンネル
+	GuildID    string `json:"guild_id"`              // チャンネルのあるギルド
+	Time       string `json:"time"`                  // 配信する時刻（schedule_timezoneでの「15:04」）
+	CityID     int    `json:"city_id"`               // 天気予報の都市ID
+	LastPosted string `json:"last_posted,omitempty"` // 最後に配信した日付（schedule_timezoneでの「2006-01-02」）
+}
+
+// dueAt はnowの日付での配信時刻を返す
+func (s *WeatherSchedule) dueAt(now time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", s.Time)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), nil
+}
+
+// due はnow（schedule_timezoneでの時刻）の時点で配信すべきかを返す
+// 今日まだ配信しておらず、配信時刻からscheduleCatchUp以内なら配信する
+func (s *WeatherSchedule) due(now time.Time) (bool, error) {
+	if s.LastPosted == now.Format(time.DateOnly) {
+		return false, nil
+	}
+	dueAt, err := s.dueAt(now)
+	if err != nil {
+		return false, err
+	}
+	return !now.Before(dueAt) && now.Sub(dueAt) <= scheduleCatchUp, nil
+}
+
+// startFrom はnow（schedule_timezoneでの時刻）に設定した定期配信を、いつから配信するかを決める
+// 今日の配信時刻を過ぎてから設定した場合は、今日は配信済みとして明日から配信する
+func (s *WeatherSchedule) startFrom(now time.Time) {
+	if dueAt, err := s.dueAt(now); err == nil && !now.Before(dueAt) {
+		s.LastPosted = now.Format(time.DateOnly)
+	}
+}
+
+// runScheduler は定期配信の時刻になったものを配信し、rain_alert.intervalごとに雨のお知らせを確かめ続ける（b.ctxがキャンセルされるまで）
+func (b *KizunaBot) runScheduler() {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	var lastRainCheck time.Time
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Discordとの接続が切れている間は配信できないので、再接続してから配信する
+		if !b.gateway.connected.Load() {
+			continue
+		}
+
+		// 終了処理の間は、配信の途中でデータベースが閉じられないよう完了を待ってもらう
+		done, ok := b.handlers.begin("天気予報の定期配信・雨のお知らせ")
+		if !ok {
+			return
+		}
+		now := time.Now()
+		b.postScheduledWeather(now)
+		if now.Sub(lastRainCheck) >= b.cfg().RainAlert.Interval {
+			b.checkRainAlerts()
+			lastRainCheck = now
+		}
+		done()
+	}
+}
+
+// postScheduledWeather は配信の時刻を過ぎていて、今日まだ配信していない定期配信を全て配信する
+func (b *KizunaBot) postScheduledWeather(now time.Time) {
+	now = now.In(b.cfg().ScheduleLocation())
+	today := now.Format(time.DateOnly)
+
+	var due []*WeatherSchedule
+	err := b.store.ForEach(store.BucketWeatherSchedules, func(key string, decode func(v any) error) error {
+		schedule := &WeatherSchedule{}
+		if err := decode(schedule); err != nil {
+			slog.Error("天気予報の定期配信の設定の読み込みに失敗しました", "channel_id", key, "error", err)
+			return nil
+		}
+		ok, err := schedule.due(now)
+		if err != nil {
+			slog.Error("天気予報の定期配信の時刻が正しくありません", "channel_id", key, "time", schedule.Time, "error", err)
+			return nil
+		}
+		if ok && !b.postedToday(schedule.ChannelID, today) {
+			due = append(due, schedule)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("天気予報の定期配信の設定の読み込みに失敗しました", "error", err)
+		return
+	}
+
+	for _, schedule := range due {
+		b.postWeatherSchedule(schedule, today)
+	}
+}
+
+// postedToday は保存に失敗した場合も含め、このプロセスで今日すでにチャンネルへ配信したかを返す
+func (b *KizunaBot) postedToday(channelID, today string) bool {
+	b.scheduleMu.Lock()
+	defer b.scheduleMu.Unlock()
+	return b.schedulePosted[channelID] == today
+}
+
+// postWeatherSchedule は1つのチャンネルに天気予報を配信し、今日は配信済みとして記録する
+// 配信に失敗した場合は記録せず、次の確認の時にもう一度試す
+func (b *KizunaBot) postWeatherSchedule(schedule *WeatherSchedule, today string) {
+	ctx := logging.With(b.ctx, "correlation_id", logging.NewCorrelationID(), "guild_id", schedule.GuildID, "channel_id", schedule.ChannelID)
+	logger := logging.FromContext(ctx)
+
+	report, err := b.apiClient.GetWeatherReportContext(ctx, schedule.CityID, api.WeatherOptions{})
+	if err != nil {
+		logger.Log(ctx, apiErrorLevel(err), "定期配信の天気情報の取得に失敗しました", "error", err)
+		return
+	}
+	if _, err := b.messenger.Send(schedule.ChannelID, "おはよう！ 今日の天気だよ :sunny:\n"+report); err != nil {
+		logger.Warn("天気予報の定期配信に失敗しました", "error", err)
+		return
+	}
+
+	logger.Info("天気予報を定期配信しました", "city_id", schedule.CityID)
+
+	// 配信している間に設定が変わっていても上書きしないよう、読み込み直してから配信した日付だけを記録する
+	b.scheduleMu.Lock()
+	defer b.scheduleMu.Unlock()
+
+	// 保存に失敗しても、このプロセスでは同じ日の配信を繰り返さない
+	b.schedulePosted[schedule.ChannelID] = today
+
+	current := &WeatherSchedule{}
+	found, err := b.store.Get(store.BucketWeatherSchedules, schedule.ChannelID, current)
+	if err != nil {
+		logger.Error("天気予報の定期配信の設定の読み込みに失敗しました", "error", err)
+		return
+	}
+	// 時刻や地域が変わった場合は、新しい設定として改めて配信するかを決める
+	if !found || current.Time != schedule.Time || current.CityID != schedule.CityID {
+		delete(b.schedulePosted, schedule.ChannelID)
+		return
+	}
+
+	// 再接続や再起動の後に同じ日の配信を繰り返さないよう、配信した日付を保存する
+	current.LastPosted = today
+	if err := b.store.Put(store.BucketWeatherSchedules, current.ChannelID, current); err != nil {
+		logger.Error("天気予報の定期配信の記録に失敗しました", "error", err)
+	}
+}
+
+// handleWeatherSchedule はチャンネルへの天気予報の定期配信を表示・設定する（管理者専用）
+// 引数は「07:00 大阪」のような時刻と地名、または定期配信をやめる「off」
+func (b *KizunaBot) handleWeatherSchedule(ctx *CommandContext, args []string) {
+	prefix := ctx.Guild.CommandPrefix()
+	if ctx.GuildID == "" {
+		ctx.Reply(fmt.Sprintf("%sweather schedule はサーバーの中で使ってね！", prefix))
+		return
+	}
+
+	// 配信した日付の記録と、設定の変更が上書きし合わないようにする
+	b.scheduleMu.Lock()
+	defer b.scheduleMu.Unlock()
+
+	schedule := &WeatherSchedule{}
+	found, err := b.store.Get(store.BucketWeatherSchedules, ctx.ChannelID, schedule)
+	if err != nil {
+		ctx.Logger().Error("天気予報の定期配信の設定の読み込みに失敗しました", "error", err)
+		ctx.Reply("設定の読み込みに失敗しました。しばらく時間をおいてからお試しください。")
+		return
+	}
+
+	// 引数がなければ現在の設定を表示
+	if len(args) == 0 {
+		if !found {
+			ctx.Reply(fmt.Sprintf("このチャンネルには天気予報を配信していないよ！ 「%sweather schedule 07:00 東京」みたいに設定してね", prefix))
+			return
+		}
+		ctx.Reply(fmt.Sprintf("このチャンネルには毎日%s（%s）に%sの天気予報を配信しているよ！",
+			schedule.Time, b.cfg().ScheduleTimezone, cityLabel(schedule.CityID)))
+		return
+	}
+
+	if !ctx.IsAdmin() {
+		ctx.Reply(fmt.Sprintf("ごめんね、%sweather schedule の設定はサーバーの管理者さんしかできないんだ", prefix))
+		return
+	}
+
+	if strings.ToLower(args[0]) == scheduleOff {
+		delete(b.schedulePosted, ctx.ChannelID)
+		if err := b.store.Delete(store.BucketWeatherSchedules, ctx.ChannelID); err != nil {
+			ctx.Logger().Error("天気予報の定期配信の設定の削除に失敗しました", "error", err)
+			ctx.Reply("設定の保存に失敗しました。しばらく時間をおいてからお試しください。")
+			return
+		}
+		ctx.Reply("このチャンネルへの天気予報の配信をやめたよ")
+		return
+	}
+
+	t, err := time.Parse("15:04", args[0])
+	if err != nil {
+		ctx.Reply(fmt.Sprintf("時刻は「07:00」みたいに書いてね！ 例: %sweather schedule 07:00 東京", prefix))
+		return
+	}
+
+	cityID := b.weatherCityID(ctx.Guild)
+	if place := strings.Join(args[1:], " "); place != "" {
+		city, suggestions, ok := findCity(place)
+		if !ok {
+			ctx.Reply(cityNotFoundMessage(prefix, place, suggestions))
+			return
+		}
+		cityID = city.ID
+	}
+
+	schedule = &WeatherSchedule{
+		ChannelID: ctx.ChannelID,
+		GuildID:   ctx.GuildID,
+		Time:      t.Format("15:04"),
+		CityID:    cityID,
+	}
+	schedule.startFrom(time.Now().In(b.cfg().ScheduleLocation()))
+	// 新しい設定では、今日配信するかを改めてLastPostedで決める
+	delete(b.schedulePosted, ctx.ChannelID)
+
+	if err := b.store.Put(store.BucketWeatherSchedules, ctx.ChannelID, schedule); err != nil {
+		ctx.Logger().Error("天気予報の定期配信の設定の保存に失敗しました", "error", err)
+		ctx.Reply("設定の保存に失敗しました。しばらく時間をおいてからお試しください。")
+		return
+	}
+	ctx.Reply(fmt.Sprintf("毎日%s（%s）に、このチャンネルへ%sの天気予報を配信するね！",
+		schedule.Time, b.cfg().ScheduleTimezone, cityLabel(cityID)))
+}
@@ -0,0 +1,157 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"kizuna_bot_go/internal/store"
+)
+
+var tokyo = mustLoadLocation("Asia/Tokyo")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// jst はschedule_timezone（Asia/Tokyo）での2026年10月18日の時刻を返す
+func jst(hour, min int) time.Time {
+	return time.Date(2026, 10, 18, hour, min, 0, 0, tokyo)
+}
+
+func TestWeatherScheduleDue(t *testing.T) {
+	tests := []struct {
+		name       string
+		time       string
+		lastPosted string
+		now        time.Time
+		want       bool
+	}{
+		{name: "配信時刻の前", time: "07:00", now: jst(6, 59), want: false},
+		{name: "配信時刻ちょうど", time: "07:00", now: jst(7, 0), want: true},
+		{name: "遅れても1時間以内なら配信する", time: "07:00", now: jst(8, 0), want: true},
+		{name: "1時間を過ぎたら今日は配信しない", time: "07:00", now: jst(8, 1), want: false},
+		{name: "今日は配信済み", time: "07:00", lastPosted: "2026-10-18", now: jst(7, 30), want: false},
+		{name: "昨日配信した", time: "07:00", lastPosted: "2026-10-17", now: jst(7, 30), want: true},
+		{name: "日付が変わる直前", time: "23:59", now: jst(23, 59), want: true},
+		// 0時の配信を23時台に遅れて配信することはない（前日の扱いにしない）
+		{name: "0時の配信の前日", time: "00:00", now: jst(23, 30), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := &WeatherSchedule{Time: tt.time, LastPosted: tt.lastPosted}
+			got, err := schedule.due(tt.now)
+			if err != nil {
+				t.Fatalf("due: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("due(%s) = %v, want %v", tt.now.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (&WeatherSchedule{Time: "7時"}).due(jst(7, 0)); err == nil {
+		t.Error("due with an invalid time did not fail")
+	}
+}
+
+func TestWeatherScheduleStartFrom(t *testing.T) {
+	tests := []struct {
+		name           string
+		now            time.Time
+		wantLastPosted string
+	}{
+		{name: "配信時刻の前に設定したら今日から", now: jst(6, 0), wantLastPosted: ""},
+		{name: "配信時刻ちょうどに設定したら明日から", now: jst(7, 0), wantLastPosted: "2026-10-18"},
+		{name: "配信時刻の後に設定したら明日から", now: jst(12, 0), wantLastPosted: "2026-10-18"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := &WeatherSchedule{Time: "07:00"}
+			schedule.startFrom(tt.now)
+			if schedule.LastPosted != tt.wantLastPosted {
+				t.Errorf("LastPosted = %q, want %q", schedule.LastPosted, tt.wantLastPosted)
+			}
+		})
+	}
+}
+
+func TestPostScheduledWeather(t *testing.T) {
+	server, useServer := newForecastServer(t)
+	server.setForecasts(forecast("2026-10-18", "今日", "晴れ"))
+	b, fake := newTestBot(t, useServer)
+
+	schedule := &WeatherSchedule{ChannelID: "c1", GuildID: testGuildID, Time: "07:00", CityID: 130010}
+	if err := b.store.Put(store.BucketWeatherSchedules, "c1", schedule); err != nil {
+		t.Fatal(err)
+	}
+
+	// 時刻はschedule_timezoneで解釈する（UTCの10月17日21:59は日本時間の10月18日6:59）
+	b.postScheduledWeather(time.Date(2026, 10, 17, 21, 59, 0, 0, time.UTC))
+	if got := len(fake.Sent()); got != 0 {
+		t.Fatalf("posted %d messages before 07:00 JST", got)
+	}
+	b.postScheduledWeather(time.Date(2026, 10, 17, 22, 0, 0, 0, time.UTC))
+	if got := len(fake.Sent()); got != 1 {
+		t.Fatalf("posted %d messages at 07:00 JST, want 1", got)
+	}
+
+	saved := &WeatherSchedule{}
+	b.store.Get(store.BucketWeatherSchedules, "c1", saved)
+	if saved.LastPosted != "2026-10-18" {
+		t.Errorf("LastPosted = %q, want 2026-10-18", saved.LastPosted)
+	}
+
+	// 同じ日には繰り返さない（日付の保存に失敗していても）
+	saved.LastPosted = ""
+	b.store.Put(store.BucketWeatherSchedules, "c1", saved)
+	b.postScheduledWeather(time.Date(2026, 10, 17, 22, 0, 30, 0, time.UTC))
+	if got := len(fake.Sent()); got != 1 {
+		t.Errorf("posted %d messages, want no repeat on the same day", got)
+	}
+
+	// 次の日はまた配信する
+	b.postScheduledWeather(time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC))
+	if got := len(fake.Sent()); got != 2 {
+		t.Errorf("posted %d messages, want 2 on the next day", got)
+	}
+}
+
+func TestPostScheduledWeatherKeepsEditsMadeWhilePosting(t *testing.T) {
+	server, useServer := newForecastServer(t)
+	server.setForecasts(forecast("2026-10-18", "今日", "晴れ"))
+	b, fake := newTestBot(t, useServer)
+
+	schedule := &WeatherSchedule{ChannelID: "c1", GuildID: testGuildID, Time: "07:00", CityID: 130010}
+	b.store.Put(store.BucketWeatherSchedules, "c1", schedule)
+
+	// 天気予報を取得している間に、管理者が配信時刻を変える
+	edited := &WeatherSchedule{ChannelID: "c1", GuildID: testGuildID, Time: "09:00", CityID: 130010}
+	server.setOnRequest(func() {
+		b.scheduleMu.Lock()
+		defer b.scheduleMu.Unlock()
+		b.store.Put(store.BucketWeatherSchedules, "c1", edited)
+	})
+	b.postScheduledWeather(jst(7, 0))
+	if got := len(fake.Sent()); got != 1 {
+		t.Fatalf("posted %d messages, want 1", got)
+	}
+
+	saved := &WeatherSchedule{}
+	b.store.Get(store.BucketWeatherSchedules, "c1", saved)
+	if saved.Time != "09:00" || saved.LastPosted != "" {
+		t.Errorf("saved schedule = %+v, want the edit kept and not marked as posted", saved)
+	}
+
+	// 新しい配信時刻には、今日も配信する
+	server.setOnRequest(nil)
+	b.postScheduledWeather(jst(9, 0))
+	if got := len(fake.Sent()); got != 2 {
+		t.Errorf("posted %d messages, want 2 after the edited time", got)
+	}
+}
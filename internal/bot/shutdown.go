@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// handlerTracker は実行中のイベントハンドラーを記録し、終了時にそれらの完了を待てるようにする
+type handlerTracker struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	stopped bool              // trueになった後は新しいハンドラーを受け付けない
+	nextID  uint64            // 次に開始するハンドラーに割り当てる番号
+	running map[uint64]string // 実行中のハンドラーの番号と内容（ログ表示用）
+}
+
+// newHandlerTracker は新しいhandlerTrackerを作成
+func newHandlerTracker() *handlerTracker {
+	return &handlerTracker{running: make(map[uint64]string)}
+}
+
+// begin はハンドラーの開始を記録し、終了時に呼ぶ関数を返す
+// 終了処理が始まっている場合はokがfalseになり、そのイベントは処理しない
+func (t *handlerTracker) begin(description string) (done func(), ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return nil, false
+	}
+
+	id := t.nextID
+	t.nextID++
+	t.running[id] = description
+	t.wg.Add(1)
+
+	return func() {
+		t.mu.Lock()
+		delete(t.running, id)
+		t.mu.Unlock()
+		t.wg.Done()
+	}, true
+}
+
+// closing は終了処理が始まっているかどうかを返す
+func (t *handlerTracker) closing() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stopped
+}
+
+// drain は新しいハンドラーの受け付けを止め、実行中のハンドラーが終わるまで最大gracePeriodだけ待つ
+// 待ちきれなかったハンドラーの内容を返す（全て終わった場合は空）
+func (t *handlerTracker) drain(gracePeriod time.Duration) []string {
+	t.mu.Lock()
+	t.stopped = true
+	count := len(t.running)
+	t.mu.Unlock()
+
+	if count > 0 {
+		slog.Info("実行中の処理が終わるのを待ちます", "count", count, "grace_period", gracePeriod)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-time.After(gracePeriod):
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var abandoned []string
+	for _, description := range t.running {
+		abandoned = append(abandoned, description)
+	}
+	sort.Strings(abandoned)
+	return abandoned
+}
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHandlerTrackerRefusesAfterDrain(t *testing.T) {
+	tracker := newHandlerTracker()
+	if tracker.closing() {
+		t.Fatal("closing = true before drain")
+	}
+
+	tracker.drain(time.Millisecond)
+
+	if !tracker.closing() {
+		t.Error("closing = false after drain")
+	}
+	if done, ok := tracker.begin("メッセージ"); ok || done != nil {
+		t.Error("begin() accepted a handler after drain started")
+	}
+}
+
+func TestHandlerTrackerDrainWaitsForHandlers(t *testing.T) {
+	tracker := newHandlerTracker()
+	doneA, _ := tracker.begin("メッセージA")
+	doneB, _ := tracker.begin("メッセージB")
+
+	// 実行中のハンドラーは少し後に終わる
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		doneA()
+		doneB()
+	}()
+
+	start := time.Now()
+	if abandoned := tracker.drain(10 * time.Second); len(abandoned) != 0 {
+		t.Errorf("drain() = %v, want none abandoned", abandoned)
+	}
+	// 全て終わったら猶予期間を使い切らずに戻る
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("drain took %v, want it to return as soon as the handlers finished", elapsed)
+	}
+}
+
+func TestHandlerTrackerDrainReportsAbandoned(t *testing.T) {
+	tracker := newHandlerTracker()
+	finished, _ := tracker.begin("終わったメッセージ")
+	stuckB, _ := tracker.begin("止まったメッセージB")
+	stuckA, _ := tracker.begin("止まったメッセージA")
+	finished()
+	defer stuckA()
+	defer stuckB()
+
+	abandoned := tracker.drain(10 * time.Millisecond)
+	want := []string{"止まったメッセージA", "止まったメッセージB"}
+	if !slices.Equal(abandoned, want) {
+		t.Errorf("drain() = %v, want %v", abandoned, want)
+	}
+}
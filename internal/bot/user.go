@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"kizuna_bot_go/internal/api"
+	"kizuna_bot_go/internal/store"
+)
+
+// homeActions は /home で指定できる操作
+var homeActions = []string{"set", "clear"}
+
+// UserSettings はユーザーごとに変更できる設定（サーバーをまたいで共通）
+type UserSettings struct {
+	Home       string `json:"home,omitempty"`         // よくいる場所（/home set で登録した地名そのまま。グルメ検索の地域に使う）
+	HomeCityID int    `json:"home_city_id,omitempty"` // よくいる場所の天気予報の都市ID（地域が分からなかった場合は0）
+}
+
+// userSettings はユーザーの設定を読み込む
+// 読み込みに失敗した場合は既定の設定を返す
+func (b *KizunaBot) userSettings(userID string) *UserSettings {
+	settings := &UserSettings{}
+	if userID == "" {
+		return settings
+	}
+
+	if _, err := b.store.Get(store.BucketUserSettings, userID, settings); err != nil {
+		slog.Error("ユーザー設定の読み込みに失敗しました（既定の設定を使います）", "user_id", userID, "error", err)
+		return &UserSettings{}
+	}
+	return settings
+}
+
+// homeCityID はユーザーのよくいる場所の都市IDを返す
+// 登録されていない場合は、ギルドの設定で天気予報に使う都市IDを返す
+func (b *KizunaBot) homeCityID(userID string, guild *GuildSettings) int {
+	if settings := b.userSettings(userID); settings.HomeCityID > 0 {
+		return settings.HomeCityID
+	}
+	return b.weatherCityID(guild)
+}
+
+// handleHome はユーザーのよくいる場所を表示・登録する
+func (b *KizunaBot) handleHome(ctx *CommandContext) {
+	prefix := ctx.Guild.CommandPrefix()
+	settings := b.userSettings(ctx.Author.ID)
+
+	// 引数がなければ登録済みの場所を表示
+	if len(ctx.Args) == 0 {
+		if settings.Home == "" {
+			ctx.Reply(fmt.Sprintf("まだ場所が登録されていないよ！ 「%shome set 新宿」みたいに登録すると、天気やグルメ検索で場所を省略できるよ", prefix))
+			return
+		}
+		ctx.Reply(fmt.Sprintf("あなたのよくいる場所は「%s」だよ！（天気予報は%s）", settings.Home, homeWeatherLabel(settings)))
+		return
+	}
+
+	action := strings.ToLower(ctx.Args[0])
+	place := strings.TrimSpace(strings.Join(ctx.Args[1:], " "))
+	// アプリケーションコマンドで場所だけ指定された場合は登録として扱う
+	if action == "" && place != "" {
+		action = "set"
+	}
+
+	var message string
+	switch action {
+	case "set":
+		if place == "" {
+			ctx.Reply(fmt.Sprintf("場所も教えてね！ 例: %shome set 大阪", prefix))
+			return
+		}
+		settings.Home = place
+		settings.HomeCityID = 0
+		if city, ok := api.CityInAddress(place); ok {
+			settings.HomeCityID = city.ID
+		}
+		message = fmt.Sprintf("よくいる場所を「%s」にしたよ！ 天気予報は%sを調べるね", place, homeWeatherLabel(settings))
+		if settings.HomeCityID == 0 {
+			message = fmt.Sprintf("よくいる場所を「%s」にしたよ！ でも天気予報の地域は分からなかったから、「%shome set 東京都新宿区」みたいに県や市の名前も入れてくれると嬉しいな", place, prefix)
+		}
+	case "clear":
+		settings = &UserSettings{}
+		message = "よくいる場所の登録を消したよ"
+	default:
+		ctx.Reply(fmt.Sprintf("できるのは %s だよ！ 例: %shome set 大阪", strings.Join(homeActions, ", "), prefix))
+		return
+	}
+
+	if err := b.store.Put(store.BucketUserSettings, ctx.Author.ID, settings); err != nil {
+		ctx.Logger().Error("ユーザー設定の保存に失敗しました", "error", err)
+		ctx.Reply("設定の保存に失敗しました。しばらく時間をおいてからお試しください。")
+		return
+	}
+	ctx.Reply(message)
+}
+
+// homeWeatherLabel はよくいる場所の天気予報の地域を表示用の文字列にする
+func homeWeatherLabel(settings *UserSettings) string {
+	if settings.HomeCityID == 0 {
+		return "サーバーの設定の地域"
+	}
+	if city, ok := api.CityByID(settings.HomeCityID); ok {
+		return fmt.Sprintf("「%s」", city.Name)
+	}
+	return cityLabel(settings.HomeCityID)
+}
@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"kizuna_bot_go/internal/api"
+)
+
+// handleWeather は天気予報を送信する
+// 「/weather 大阪」のように地名が指定された場合はその地域、
+// なければユーザーのよくいる場所か、ギルドで設定された地域の天気予報を送信する
+// 「/weather detail」のようにdetailを付けると、降水確率の内訳や風・波、天気概況も送信する
+// 「/weather schedule 07:00 大阪」の場合は、チャンネルへの定期配信を設定する
+// 「/weather alert 大阪」の場合は、チャンネルへの雨のお知らせを設定する
+func (b *KizunaBot) handleWeather(ctx *CommandContext) {
+	args := ctx.Args
+	if len(args) > 0 && args[0] == weatherSchedule {
+		// アプリケーションコマンドでは時刻と地名が1つの引数にまとめて入るため、空白で分け直す
+		b.handleWeatherSchedule(ctx, strings.Fields(strings.Join(args[1:], " ")))
+		return
+	}
+	if len(args) > 0 && args[0] == weatherAlert {
+		b.handleRainAlert(ctx, strings.Fields(strings.Join(args[1:], " ")))
+		return
+	}
+	opts := api.WeatherOptions{Place: b.userSettings(ctx.Author.ID).Home}
+	// アプリケーションコマンドでmodeが省略された場合は空文字列になる
+	if len(args) > 0 && (args[0] == weatherDetail || args[0] == "") {
+		opts.Detail = args[0] == weatherDetail
+		args = args[1:]
+	}
+
+	cityID := b.homeCityID(ctx.Author.ID, ctx.Guild)
+	if place := strings.Join(args, " "); place != "" {
+		city, suggestions, ok := findCity(place)
+		if !ok {
+			ctx.Reply(cityNotFoundMessage(ctx.Guild.CommandPrefix(), place, suggestions))
+			return
+		}
+		cityID = city.ID
+		opts.Place = place
+	}
+	b.replyWeather(ctx, cityID, opts)
+}
+
+// handleWeatherQuestion は「大阪の天気は？」のようなメッセージに天気予報を返す
+func (b *KizunaBot) handleWeatherQuestion(ctx *CommandContext) {
+	cityID, reply := b.weatherCityForMessage(ctx.Message.Content, ctx.Author.ID, ctx.Guild)
+	if reply != "" {
+		ctx.Reply(reply)
+		return
+	}
+	b.replyWeather(ctx, cityID, b.weatherOptionsForMessage(ctx.Message.Content, ctx.Author.ID))
+}
+
+// findCity は地名から天気予報の地域を探す
+// 「東京都新宿区」のような住所なら、含まれている地名の地域を使う
+func findCity(place string) (api.City, []api.City, bool) {
+	city, suggestions, ok := api.LookupCity(place)
+	if !ok {
+		city, ok = api.CityInAddress(place)
+	}
+	return city, suggestions, ok
+}
+
+// replyWeather は指定された地域の天気予報を送信する
+func (b *KizunaBot) replyWeather(ctx *CommandContext, cityID int, opts api.WeatherOptions) {
+	message, err := b.apiClient.GetWeatherReportContext(ctx.Context, cityID, opts)
+	if err != nil {
+		ctx.Logger().Log(ctx.Context, apiErrorLevel(err), "天気情報の取得に失敗しました", "error", err)
+		message = apiErrorMessage(err, "天気情報")
+	}
+	ctx.Reply(message)
+}
+
+// weatherOptionsForMessage は「大阪の天気を詳しく」のようなメッセージから天気予報のメッセージの作り方を決める
+// 詳しい天気予報のリンクには、メッセージに書かれた地名があればそれを、なければユーザーのよくいる場所を使う
+func (b *KizunaBot) weatherOptionsForMessage(content, userID string) api.WeatherOptions {
+	place := extractPlace(content)
+	if _, _, ok := findCity(place); place == "" || !ok {
+		place = b.userSettings(userID).Home
+	}
+	return api.WeatherOptions{
+		Detail: strings.Contains(content, "詳しく") || strings.Contains(content, "くわしく"),
+		Place:  place,
+	}
+}
+
+// weatherCityForMessage は「大阪の天気は？」のようなメッセージから天気予報を調べる地域を決める
+// 地名は /weather と同じく、都市名のほか「東京都新宿区」のような住所でも探す
+// 地名が書かれていない・地名として見つからない場合は、ユーザーのよくいる場所かギルドで設定された地域を使う
+// 似た地名がある場合は、天気予報の代わりに聞き返すメッセージをreplyに返す
+func (b *KizunaBot) weatherCityForMessage(content, userID string, settings *GuildSettings) (cityID int, reply string) {
+	place := extractPlace(content)
+	if place == "" {
+		return b.homeCityID(userID, settings), ""
+	}
+
+	city, suggestions, ok := findCity(place)
+	if ok {
+		return city.ID, ""
+	}
+	if len(suggestions) > 0 {
+		return 0, cityNotFoundMessage(settings.CommandPrefix(), place, suggestions)
+	}
+	return b.homeCityID(userID, settings), ""
+}
+
+// /weather の最初の引数で指定できるモード
+const (
+	weatherDetail   = "detail"   // 詳しい天気予報を求める
+	weatherSchedule = "schedule" // チャンネルへの定期配信を設定する
+	weatherAlert    = "alert"    // チャンネルへの雨のお知らせを設定する
+)
+
+// timeWords は「明日の天気は？」のように「の天気」の前に来ても地名ではない言葉
+var timeWords = []string{"今日", "明日", "明後日", "きょう", "あした", "あす", "あさって", "今", "いま", "週末", "今週", "来週", "最近", "みんな"}
+
+// extractPlace は「大阪の天気は？」のようなメッセージから地名の部分を取り出す（なければ空文字列）
+func extractPlace(content string) string {
+	i := strings.Index(content, "の天気")
+	if i < 0 {
+		return ""
+	}
+
+	// 空白や句読点より後ろを地名の候補にする
+	before := content[:i]
+	if j := strings.LastIndexAny(before, " 　、。,.!！?？"); j >= 0 {
+		_, size := utf8.DecodeRuneInString(before[j:])
+		before = before[j+size:]
+	}
+
+	// 「しものせき」のように「の」を含む地名もあるため、まずは地域として見つかる一番長い末尾を探す
+	runes := []rune(before)
+	for j := range runes {
+		if _, _, ok := api.LookupCity(string(runes[j:])); ok {
+			return string(runes[j:])
+		}
+	}
+
+	// 「東京の明日」のような場合は「の」で区切り、後ろから順に地名らしいものを探す
+	segments := strings.Split(before, "の")
+	place := ""
+	for j := len(segments) - 1; j >= 0; j-- {
+		segment := strings.TrimSpace(segments[j])
+		if segment == "" || slices.Contains(timeWords, segment) {
+			continue
+		}
+		if _, _, ok := api.LookupCity(segment); ok {
+			return segment
+		}
+		if place == "" {
+			place = segment
+		}
+	}
+	return place
+}
+
+// cityNotFoundMessage は地名が見つからなかった時のメッセージを作る
+// 似た地名があれば「もしかして」と聞き返す
+func cityNotFoundMessage(prefix, place string, suggestions []api.City) string {
+	if len(suggestions) == 0 {
+		return fmt.Sprintf("「%s」って場所が見つからなかったよ……「%sweather 大阪」みたいに、県や市の名前で教えてね！", place, prefix)
+	}
+
+	names := make([]string, len(suggestions))
+	for i, city := range suggestions {
+		names[i] = fmt.Sprintf("「%s」（%s）", city.Name, city.Prefecture)
+	}
+	return fmt.Sprintf("「%s」って場所が見つからなかったよ……もしかして%sのこと？ (・・？)", place, strings.Join(names, "か"))
+}
+
+// cityLabel は都市IDを「大阪（270000）」のような表示用の文字列にする（一覧にない都市IDは数字のみ）
+func cityLabel(cityID int) string {
+	if city, ok := api.CityByID(cityID); ok {
+		return fmt.Sprintf("%s（%06d）", city.Name, city.ID)
+	}
+	return fmt.Sprintf("%06d", cityID)
+}
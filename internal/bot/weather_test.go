@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"testing"
+
+	"kizuna_bot_go/internal/api"
+)
+
+func TestWeatherCityForMessage(t *testing.T) {
+	b, fake := newTestBot(t)
+	post(b, fake, testUser, "/home set 大阪")
+	settings := b.guildSettings(testGuildID)
+	osaka, _, _ := api.LookupCity("大阪")
+	tokyo, _, _ := api.LookupCity("東京")
+	sapporo, _, _ := api.LookupCity("札幌")
+
+	tests := []struct {
+		content   string
+		wantCity  int
+		wantReply bool
+	}{
+		{content: "札幌の天気は？", wantCity: sapporo.ID},
+		// /weather と同じく、住所からも地域を探す
+		{content: "東京都新宿区の天気は？", wantCity: tokyo.ID},
+		{content: "今日の天気は？", wantCity: osaka.ID},
+		{content: "天気どう？", wantCity: osaka.ID},
+		{content: "さっぽっろの天気は？", wantReply: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			cityID, reply := b.weatherCityForMessage(tt.content, testUser.ID, settings)
+			if tt.wantReply {
+				if reply == "" {
+					t.Errorf("weatherCityForMessage(%q) = %d, want a suggestion reply", tt.content, cityID)
+				}
+				return
+			}
+			if cityID != tt.wantCity || reply != "" {
+				t.Errorf("weatherCityForMessage(%q) = (%d, %q), want %d", tt.content, cityID, reply, tt.wantCity)
+			}
+
+			// /weather に同じ地名を渡した時と同じ地域になる
+			if place := extractPlace(tt.content); place != "" {
+				if city, _, ok := findCity(place); !ok || city.ID != cityID {
+					t.Errorf("findCity(%q) = %d, want %d", place, city.ID, cityID)
+				}
+			}
+		})
+	}
+}
+
+func TestWeatherOptionsForMessage(t *testing.T) {
+	b, fake := newTestBot(t)
+	post(b, fake, testUser, "/home set 東京都新宿区")
+
+	tests := []struct {
+		content    string
+		wantDetail bool
+		wantPlace  string
+	}{
+		{content: "天気は？", wantPlace: "東京都新宿区"},
+		{content: "東京都渋谷区の天気をくわしく", wantDetail: true, wantPlace: "東京都渋谷区"},
+		{content: "明日の天気を詳しく教えて", wantDetail: true, wantPlace: "東京都新宿区"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			opts := b.weatherOptionsForMessage(tt.content, testUser.ID)
+			if opts.Detail != tt.wantDetail || opts.Place != tt.wantPlace {
+				t.Errorf("weatherOptionsForMessage(%q) = %+v, want {Detail:%v Place:%s}", tt.content, opts, tt.wantDetail, tt.wantPlace)
+			}
+		})
+	}
+}
+
+func TestExtractPlace(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{content: "大阪の天気は？", want: "大阪"},
+		{content: "@kizuna おおさかの天気教えて", want: "おおさか"},
+		{content: "ねえ、sapporoの天気は？", want: "sapporo"},
+		// 「の」を含む地名は「の」で区切らない
+		{content: "しものせきの天気は？", want: "しものせき"},
+		{content: "明日のしものせきの天気", want: "しものせき"},
+		// 時を表す言葉は地名として扱わない
+		{content: "今日の天気は？", want: ""},
+		{content: "明日の天気は？", want: ""},
+		{content: "あしたの天気は？", want: ""},
+		{content: "東京の明日の天気", want: "東京"},
+		{content: "明日の東京の天気", want: "東京"},
+		// 見つからない地名もそのまま返す（似た地名を聞き返すため）
+		{content: "さっぽっろの天気は？", want: "さっぽっろ"},
+		{content: "東京都新宿区の天気は？", want: "東京都新宿区"},
+		{content: "天気どう？", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			if got := extractPlace(tt.content); got != tt.want {
+				t.Errorf("extractPlace(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
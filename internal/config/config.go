@@ -1,51 +1,188 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"kizuna_bot_go/internal/ratelimit"
 )
 
+// DefaultConfigFile は設定ファイルのパスが指定されなかった場合に読み込むファイル名
+const DefaultConfigFile = "config.yml"
+
 // Config はボットの動作に必要な全ての設定値を保持する構造体
 type Config struct {
 	// Discord関連の設定
-	BotClientID string // DiscordのBot Client ID
-	BotToken    string // DiscordのBotトークン（認証に使用）
+	BotClientID string `yaml:"bot_client_id"`           // DiscordのBot Client ID
+	BotToken    string `yaml:"bot_token" secret:"true"` // DiscordのBotトークン（認証に使用）
+
+	// アプリケーションコマンドを登録するギルドのID（空の場合はグローバルに登録）
+	ApplicationCommandGuildID string `yaml:"application_command_guild_id"`
+
+	// ログの出力形式とレベル
+	Log Log `yaml:"log"`
+
+	// 監視用のHTTPサーバーのアドレス（例: 127.0.0.1:9100、空なら起動しない）
+	// /metrics でPrometheus形式のメトリクスを、/healthz と /readyz でボットの状態を公開する
+	HTTPAddr string `yaml:"http_addr"`
+	// ゲートウェイとの接続が切れたまま、またはハートビートの応答がないまま、この時間が過ぎたら /healthz を失敗させる
+	HealthTimeout time.Duration `yaml:"health_timeout"`
+
+	// ボットの状態を保存するデータベースファイルのパス
+	DatabasePath string `yaml:"database_path"`
 
 	// 外部API接続用のキー
-	RSS2JSONAPIKey       string // ニュース取得用のRSS2JSON APIキー
-	RecruitAPIKey        string // グルメ検索用のリクルートAPIキー
-	CustomSearchEngineID string // 画像検索用のGoogleカスタム検索エンジンID
-	CustomSearchAPIKey   string // 画像検索用のGoogleカスタム検索APIキー
-	YouTubeDataAPIKey    string // 動画検索用のYouTube Data APIキー
+	RSS2JSONAPIKey       string `yaml:"rss2json_api_key" secret:"true"`      // ニュース取得用のRSS2JSON APIキー
+	RecruitAPIKey        string `yaml:"recruit_api_key" secret:"true"`       // グルメ検索用のリクルートAPIキー
+	CustomSearchEngineID string `yaml:"custom_search_engine_id"`             // 画像検索用のGoogleカスタム検索エンジンID
+	CustomSearchAPIKey   string `yaml:"custom_search_api_key" secret:"true"` // 画像検索用のGoogleカスタム検索APIキー
+	YouTubeDataAPIKey    string `yaml:"youtube_data_api_key" secret:"true"`  // 動画検索用のYouTube Data APIキー
 
 	// 各種APIのエンドポイント（接続先URL）
-	LivedoorWeatherAPIHost string // ライブドア天気予報API
-	RSS2JSONAPIHost        string // RSS2JSON API（ニュース取得用）
-	HotPepperAPIHost       string // ホットペッパーAPI（グルメ検索用）
-	CustomSearchAPIHost    string // Google カスタム検索API
-	YouTubeDataAPIHost     string // YouTube Data API
-	GoogleTranslateAPIHost string // Google翻訳API
+	LivedoorWeatherAPIHost string `yaml:"livedoor_weather_api_host"` // ライブドア天気予報API
+	RSS2JSONAPIHost        string `yaml:"rss2json_api_host"`         // RSS2JSON API（ニュース取得用）
+	HotPepperAPIHost       string `yaml:"hotpepper_api_host"`        // ホットペッパーAPI（グルメ検索用）
+	CustomSearchAPIHost    string `yaml:"custom_search_api_host"`    // Google カスタム検索API
+	YouTubeDataAPIHost     string `yaml:"youtube_data_api_host"`     // YouTube Data API
+	GoogleTranslateAPIHost string `yaml:"google_translate_api_host"` // Google翻訳API
 
 	// アプリケーション定数
-	TokyoCityID       int    // 天気予報で使用する東京の都市ID
-	RankTotalCount    int    // ユーザーランキング機能で取得するメッセージ数
-	HatenaHotentryRSS string // はてなホットエントリーのRSS URL
+	TokyoCityID       int    `yaml:"tokyo_city_id"`       // 天気予報で使用する東京の都市ID
+	RankTotalCount    int    `yaml:"rank_total_count"`    // ユーザーランキング機能で取得するメッセージ数
+	HatenaHotentryRSS string `yaml:"hatena_hotentry_rss"` // はてなホットエントリーのRSS URL
+
+	// 天気予報の定期配信の時刻を解釈するタイムゾーン（例: Asia/Tokyo）
+	ScheduleTimezone string `yaml:"schedule_timezone"`
+	// 雨のお知らせ（/weather alert）の設定
+	RainAlert RainAlert `yaml:"rain_alert"`
+
+	// 外部APIごとの1日の利用上限（キーは custom_search, youtube, hotpepper、0は上限なし）
+	// 各APIの提供元が定める上限に合わせ、超えた分はAPIを呼び出さずに断る
+	Quotas map[string]int `yaml:"quotas"`
+
+	// 外部APIのレスポンスのキャッシュ
+	Cache Cache `yaml:"cache"`
+
+	// 終了時に、実行中のコマンドなどの処理が終わるのを待つ最大の時間
+	ShutdownGracePeriod time.Duration `yaml:"shutdown_grace_period"`
+
+	// 外部APIへの1回のリクエストのタイムアウト
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// 外部APIの呼び出しに失敗した時の再試行
+	Retry Retry `yaml:"retry"`
+	// 失敗が続く接続先への呼び出しを一時的に止める設定
+	CircuitBreaker CircuitBreaker `yaml:"circuit_breaker"`
+
+	// コマンド名ごとの連続実行の制限（キーは別名ではなく本来のコマンド名、書かなかったコマンドは制限なし）
+	Cooldowns map[string]Cooldown `yaml:"cooldowns"`
+}
+
+// Cooldown は1つのコマンドの連続実行を制限する設定
+// ユーザーごと・チャンネルごとの制限を両方設定した場合は、どちらも満たす必要がある
+type Cooldown struct {
+	User    ratelimit.Rule `yaml:"user"`    // 同じユーザーによる実行の制限
+	Channel ratelimit.Rule `yaml:"channel"` // 同じチャンネルでの実行の制限
+}
+
+// Log はログの出力の設定
+type Log struct {
+	Format string `yaml:"format"` // 出力形式（text または json）
+	Level  string `yaml:"level"`  // 出力するレベル（debug, info, warn, error）
+}
+
+// Cache は外部APIのレスポンスのキャッシュの設定
+type Cache struct {
+	// APIごとのキャッシュの有効期間（キーは weather, news, youtube、0はキャッシュしない）
+	TTLs map[string]time.Duration `yaml:"ttls"`
+	// キャッシュをデータベースにも保存し、再起動後も使うかどうか
+	Persist bool `yaml:"persist"`
+	// APIの呼び出しに失敗した時、有効期間を過ぎたキャッシュをどれだけ前のものまで代わりに使うか
+	StaleLimit time.Duration `yaml:"stale_limit"`
+	// メモリ上に保持するキャッシュの最大件数（超えたら取得した時刻の古いものから捨てる）
+	MaxEntries int `yaml:"max_entries"`
+}
+
+// RainAlert は天気予報が雨に変わった時にお知らせする機能の設定
+type RainAlert struct {
+	Interval  time.Duration `yaml:"interval"`  // 登録された地域の天気予報を確かめる間隔
+	Threshold int           `yaml:"threshold"` // 降水確率がこの値（%）以上になったら、天気が雨でなくても知らせる
+}
+
+// Retry は外部APIの呼び出しに失敗した時の再試行の設定
+// 接続エラー・タイムアウト・5xx・429の場合に、待ち時間を倍々に延ばしながら再試行する
+type Retry struct {
+	MaxAttempts int           `yaml:"max_attempts"` // 最初の1回を含む最大試行回数（1なら再試行しない）
+	BaseDelay   time.Duration `yaml:"base_delay"`   // 1回目の再試行までの待ち時間
+	MaxDelay    time.Duration `yaml:"max_delay"`    // 待ち時間の上限（429のRetry-Afterがこれより長い場合は再試行しない）
 }
 
-// NewConfig は環境変数から設定を読み込んで新しいConfigインスタンスを作成
-func NewConfig() *Config {
+// CircuitBreaker は失敗が続く接続先（ホスト）への呼び出しを一時的に止める設定
+type CircuitBreaker struct {
+	Threshold int           `yaml:"threshold"` // 続けて何回失敗したら止めるか（0は止めない）
+	Cooldown  time.Duration `yaml:"cooldown"`  // 止めてから試しに呼び出してみるまでの時間
+}
+
+// envBindings は環境変数名と、その値で上書きするConfigのフィールドの対応表
+// 環境変数は設定ファイルよりも優先される
+var envBindings = []struct {
+	name  string
+	field func(c *Config) *string
+}{
+	{"BOT_CLIENT_ID", func(c *Config) *string { return &c.BotClientID }},
+	{"BOT_TOKEN", func(c *Config) *string { return &c.BotToken }},
+	{"APPLICATION_COMMAND_GUILD_ID", func(c *Config) *string { return &c.ApplicationCommandGuildID }},
+	{"DATABASE_PATH", func(c *Config) *string { return &c.DatabasePath }},
+	{"HTTP_ADDR", func(c *Config) *string { return &c.HTTPAddr }},
+	{"LOG_FORMAT", func(c *Config) *string { return &c.Log.Format }},
+	{"LOG_LEVEL", func(c *Config) *string { return &c.Log.Level }},
+	{"RSS2JSON_API_KEY", func(c *Config) *string { return &c.RSS2JSONAPIKey }},
+	{"RECRUIT_API_KEY", func(c *Config) *string { return &c.RecruitAPIKey }},
+	{"CUSTOM_SEARCH_ENGINE_ID", func(c *Config) *string { return &c.CustomSearchEngineID }},
+	{"CUSTOM_SEARCH_API_KEY", func(c *Config) *string { return &c.CustomSearchAPIKey }},
+	{"YOUTUBE_DATA_API_KEY", func(c *Config) *string { return &c.YouTubeDataAPIKey }},
+}
+
+// Load は既定値・設定ファイル・環境変数の順に設定を重ねて読み込み、内容を検証する
+// pathが空の場合はDefaultConfigFileを探し、存在しなければ設定ファイルなしで続行する
+func Load(path string) (*Config, error) {
+	cfg := defaultConfig()
+
+	optional := path == ""
+	if optional {
+		path = DefaultConfigFile
+	}
+
+	if err := cfg.loadFile(path); err != nil {
+		if !(optional && errors.Is(err, os.ErrNotExist)) {
+			return nil, err
+		}
+	}
+
+	cfg.applyEnv()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// defaultConfig は設定ファイルや環境変数で上書きされる前の既定値を返す
+func defaultConfig() *Config {
 	return &Config{
-		// Discord関連の環境変数を取得
-		BotClientID: os.Getenv("BOT_CLIENT_ID"),
-		BotToken:    os.Getenv("BOT_TOKEN"),
-
-		// 外部API用のキーを環境変数から取得
-		RSS2JSONAPIKey:       os.Getenv("RSS2JSON_API_KEY"),
-		RecruitAPIKey:        os.Getenv("RECRUIT_API_KEY"),
-		CustomSearchEngineID: os.Getenv("CUSTOM_SEARCH_ENGINE_ID"),
-		CustomSearchAPIKey:   os.Getenv("CUSTOM_SEARCH_API_KEY"),
-		YouTubeDataAPIKey:    os.Getenv("YOUTUBE_DATA_API_KEY"),
-
-		// 各APIのエンドポイントURL（固定値）
+		Log:          Log{Format: "text", Level: "info"},
+		DatabasePath: "kizuna.db",
+
+		// 再接続にかかる時間より長く、ハートビートの間隔（約40秒）の数回分
+		HealthTimeout: 5 * time.Minute,
+
+		// 各APIのエンドポイントURL
 		LivedoorWeatherAPIHost: "https://weather.tsukumijima.net/api/forecast",
 		RSS2JSONAPIHost:        "https://api.rss2json.com/v1/api.json",
 		HotPepperAPIHost:       "https://webservice.recruit.co.jp/hotpepper/gourmet/v1",
@@ -57,5 +194,209 @@ func NewConfig() *Config {
 		TokyoCityID:       130010,                                     // ライブドア天気APIでの東京の都市コード
 		RankTotalCount:    200,                                        // ユーザーランキングで過去何件のメッセージを集計するか
 		HatenaHotentryRSS: "https://b.hatena.ne.jp/hotentry?mode=rss", // はてなホットエントリーのRSS配信URL
+		ScheduleTimezone:  "Asia/Tokyo",                               // 定期配信の時刻は日本時間で指定する
+
+		// 天気予報のキャッシュの有効期間に合わせて確かめる
+		RainAlert: RainAlert{
+			Interval:  30 * time.Minute,
+			Threshold: 50,
+		},
+
+		// 無料枠での1日の利用上限
+		Quotas: map[string]int{
+			"custom_search": 100,   // Google カスタム検索: 1日100クエリ
+			"youtube":       10000, // YouTube Data API: 1日10000ユニット（検索1回で100ユニット）
+			"hotpepper":     0,     // ホットペッパー: 公開された上限なし（回数だけ数える）
+		},
+
+		// 更新頻度の低いAPIのレスポンスはしばらく使い回す
+		Cache: Cache{
+			TTLs: map[string]time.Duration{
+				"weather": 30 * time.Minute,
+				"news":    10 * time.Minute,
+				"youtube": time.Hour,
+			},
+			StaleLimit: 24 * time.Hour,
+			MaxEntries: 1000,
+		},
+
+		// systemdの既定の停止タイムアウト（90秒）より十分短くしておく
+		ShutdownGracePeriod: 15 * time.Second,
+
+		// 外部APIが応答しない時に、ユーザーを長く待たせないようにする
+		RequestTimeout: 10 * time.Second,
+		Retry: Retry{
+			MaxAttempts: 3,
+			BaseDelay:   500 * time.Millisecond,
+			MaxDelay:    5 * time.Second,
+		},
+		CircuitBreaker: CircuitBreaker{
+			Threshold: 5,
+			Cooldown:  time.Minute,
+		},
+
+		// 1日あたりの回数に上限のあるAPIを使うコマンドは、既定で連続実行を制限する
+		Cooldowns: map[string]Cooldown{
+			"image": {
+				User:    ratelimit.Rule{Burst: 3, Interval: 10 * time.Minute},
+				Channel: ratelimit.Rule{Burst: 10, Interval: 10 * time.Minute},
+			},
+			"video": {
+				User: ratelimit.Rule{Burst: 3, Interval: 5 * time.Minute},
+			},
+			"vtuber": {
+				User: ratelimit.Rule{Burst: 3, Interval: 5 * time.Minute},
+			},
+			"gourmet": {
+				User: ratelimit.Rule{Burst: 5, Interval: time.Minute},
+			},
+			"rank": {
+				Channel: ratelimit.Rule{Burst: 1, Interval: time.Minute},
+			},
+		},
+	}
+}
+
+// ScheduleLocation は定期配信の時刻を解釈するタイムゾーンを返す
+// Validateで検証済みのため、読み込みに失敗した場合（通常は起こらない）はUTCを返す
+func (c *Config) ScheduleLocation() *time.Location {
+	loc, err := time.LoadLocation(c.ScheduleTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// loadFile はYAML形式の設定ファイルを読み込み、記載されている項目だけを上書きする
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	// 項目名の書き間違いに気付けるよう、未知の項目はエラーにする
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv は設定されている環境変数の値で各項目を上書きする
+func (c *Config) applyEnv() {
+	for _, binding := range envBindings {
+		if value := os.Getenv(binding.name); value != "" {
+			*binding.field(c) = value
+		}
+	}
+}
+
+// Validate は設定値が正しい形式かどうかを検証し、問題があれば全てまとめてエラーとして返す
+// APIキーの未設定はエラーにせず、DisabledFeaturesで報告する
+func (c *Config) Validate() error {
+	var errs []error
+
+	hosts := []struct{ name, value string }{
+		{"livedoor_weather_api_host", c.LivedoorWeatherAPIHost},
+		{"rss2json_api_host", c.RSS2JSONAPIHost},
+		{"hotpepper_api_host", c.HotPepperAPIHost},
+		{"custom_search_api_host", c.CustomSearchAPIHost},
+		{"youtube_data_api_host", c.YouTubeDataAPIHost},
+		{"google_translate_api_host", c.GoogleTranslateAPIHost},
+		{"hatena_hotentry_rss", c.HatenaHotentryRSS},
+	}
+	for _, host := range hosts {
+		if u, err := url.Parse(host.value); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s must be an absolute URL: %q", host.name, host.value))
+		}
+	}
+
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		errs = append(errs, fmt.Errorf("log.format must be text or json: %q", c.Log.Format))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("log.level must be debug, info, warn or error: %q", c.Log.Level))
+	}
+
+	if c.DatabasePath == "" {
+		errs = append(errs, errors.New("database_path must not be empty"))
+	}
+	if c.TokyoCityID <= 0 {
+		errs = append(errs, fmt.Errorf("tokyo_city_id must be positive: %d", c.TokyoCityID))
+	}
+	if c.RankTotalCount <= 0 {
+		errs = append(errs, fmt.Errorf("rank_total_count must be positive: %d", c.RankTotalCount))
+	}
+	if _, err := time.LoadLocation(c.ScheduleTimezone); err != nil || c.ScheduleTimezone == "" {
+		errs = append(errs, fmt.Errorf("schedule_timezone must be a valid time zone name: %q", c.ScheduleTimezone))
+	}
+	if c.RainAlert.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("rain_alert.interval must be positive: %s", c.RainAlert.Interval))
+	}
+	if c.RainAlert.Threshold < 1 || c.RainAlert.Threshold > 100 {
+		errs = append(errs, fmt.Errorf("rain_alert.threshold must be between 1 and 100: %d", c.RainAlert.Threshold))
+	}
+
+	for name, limit := range c.Quotas {
+		if !slices.Contains(QuotaUpstreams, name) {
+			errs = append(errs, fmt.Errorf("quotas.%s is not a known API (use %s)", name, strings.Join(QuotaUpstreams, ", ")))
+		}
+		if limit < 0 {
+			errs = append(errs, fmt.Errorf("quotas.%s must not be negative: %d", name, limit))
+		}
+	}
+
+	for name, ttl := range c.Cache.TTLs {
+		if !slices.Contains(CachedAPIs, name) {
+			errs = append(errs, fmt.Errorf("cache.ttls.%s is not a known API (use %s)", name, strings.Join(CachedAPIs, ", ")))
+		}
+		if ttl < 0 {
+			errs = append(errs, fmt.Errorf("cache.ttls.%s must not be negative: %s", name, ttl))
+		}
+	}
+	if c.Cache.StaleLimit < 0 {
+		errs = append(errs, fmt.Errorf("cache.stale_limit must not be negative: %s", c.Cache.StaleLimit))
+	}
+	if c.Cache.MaxEntries <= 0 {
+		errs = append(errs, fmt.Errorf("cache.max_entries must be positive: %d", c.Cache.MaxEntries))
+	}
+
+	if c.HealthTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("health_timeout must be positive: %s", c.HealthTimeout))
+	}
+	if c.ShutdownGracePeriod < 0 {
+		errs = append(errs, fmt.Errorf("shutdown_grace_period must not be negative: %s", c.ShutdownGracePeriod))
+	}
+	if c.RequestTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("request_timeout must be positive: %s", c.RequestTimeout))
+	}
+	if c.Retry.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("retry.max_attempts must be at least 1: %d", c.Retry.MaxAttempts))
+	}
+	if c.Retry.BaseDelay < 0 || c.Retry.MaxDelay < 0 {
+		errs = append(errs, errors.New("retry delays must not be negative"))
+	}
+	if c.CircuitBreaker.Threshold < 0 || c.CircuitBreaker.Cooldown < 0 {
+		errs = append(errs, errors.New("circuit_breaker must not be negative"))
+	}
+
+	for name, cooldown := range c.Cooldowns {
+		if !slices.Contains(Commands, name) {
+			errs = append(errs, fmt.Errorf("cooldowns.%s is not a known command name", name))
+		}
+		for _, rule := range []ratelimit.Rule{cooldown.User, cooldown.Channel} {
+			if rule.Burst < 0 || rule.Interval < 0 {
+				errs = append(errs, fmt.Errorf("cooldowns.%s must not be negative", name))
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
 	}
+	return nil
 }
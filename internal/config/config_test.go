@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv は設定を上書きする環境変数を全て空にする（テストを動かす環境の変数に影響されないように）
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, binding := range envBindings {
+		t.Setenv(binding.name, "")
+	}
+}
+
+// writeConfig は一時ディレクトリに設定ファイルを書き、そのパスを返す
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	clearEnv(t)
+	t.Chdir(t.TempDir())
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.TokyoCityID != 130010 || cfg.RankTotalCount != 200 {
+		t.Errorf("Load without a file did not return the defaults: %+v", cfg)
+	}
+
+	// パスを指定した場合はファイルがなければエラー
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Error("Load with a missing file did not fail")
+	}
+}
+
+func TestLoadFileOverridesDefaults(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, `
+rank_total_count: 50
+application_command_guild_id: "123456789012345678"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.RankTotalCount != 50 {
+		t.Errorf("RankTotalCount = %d, want 50", cfg.RankTotalCount)
+	}
+	if cfg.ApplicationCommandGuildID != "123456789012345678" {
+		t.Errorf("ApplicationCommandGuildID = %q, want 123456789012345678", cfg.ApplicationCommandGuildID)
+	}
+	// ファイルに書かなかった項目は既定値のまま
+	if cfg.TokyoCityID != defaultConfig().TokyoCityID || cfg.HatenaHotentryRSS != defaultConfig().HatenaHotentryRSS {
+		t.Errorf("fields missing from the file lost their defaults: tokyo_city_id=%d hatena_hotentry_rss=%q", cfg.TokyoCityID, cfg.HatenaHotentryRSS)
+	}
+}
+
+func TestLoadEnvOverridesFile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("BOT_TOKEN", "from-env")
+	path := writeConfig(t, `
+bot_token: from-file
+recruit_api_key: recruit-from-file
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.BotToken != "from-env" {
+		t.Errorf("BotToken = %q, want from-env", cfg.BotToken)
+	}
+	// 環境変数が設定されていない項目はファイルの値
+	if cfg.RecruitAPIKey != "recruit-from-file" {
+		t.Errorf("RecruitAPIKey = %q, want recruit-from-file from the file", cfg.RecruitAPIKey)
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "トップレベル", content: "rank_totl_count: 50\n", want: "rank_totl_count"},
+		{name: "入れ子", content: "log:\n  levle: debug\n", want: "levle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			_, err := Load(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load = %v, want an error about %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.RSS2JSONAPIHost = "api.rss2json.com"
+	cfg.DatabasePath = ""
+	cfg.TokyoCityID = 0
+	cfg.RankTotalCount = -1
+	cfg.Log.Format = "xml"
+	cfg.ScheduleTimezone = "Mars/Olympus"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate did not fail")
+	}
+	for _, want := range []string{"rss2json_api_host", "database_path", "tokyo_city_id", "rank_total_count", "log.format", "schedule_timezone"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s:\n%v", want, err)
+		}
+	}
+
+	if err := defaultConfig().Validate(); err != nil {
+		t.Errorf("defaults are invalid: %v", err)
+	}
+}
+
+func TestValidateRejectsUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   string // 空ならエラーにならない
+	}{
+		{name: "quotasの知っているキー", modify: func(cfg *Config) { cfg.Quotas["youtube"] = 500 }},
+		{name: "quotasの書き間違い", modify: func(cfg *Config) { cfg.Quotas["youtub"] = 500 }, want: "quotas.youtub"},
+		{name: "cache.ttlsの知っているキー", modify: func(cfg *Config) { cfg.Cache.TTLs["news"] = time.Minute }},
+		{name: "cache.ttlsの書き間違い", modify: func(cfg *Config) { cfg.Cache.TTLs["wether"] = time.Minute }, want: "cache.ttls.wether"},
+		{name: "cooldownsの知っているコマンド", modify: func(cfg *Config) { cfg.Cooldowns["dice"] = Cooldown{} }},
+		{name: "cooldownsの書き間違い", modify: func(cfg *Config) { cfg.Cooldowns["imgae"] = Cooldown{} }, want: "cooldowns.imgae"},
+		// cooldownsには別名ではなく本来のコマンド名を書く
+		{name: "cooldownsに別名", modify: func(cfg *Config) { cfg.Cooldowns["img"] = Cooldown{} }, want: "cooldowns.img"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Validate: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Validate = %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+}
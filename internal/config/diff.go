@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Diff は2つの設定を比較し、値が変わった項目を「項目名: 変更前 -> 変更後」の形式で返す
+// secretタグの付いた項目（トークンやAPIキー）は値を伏せて、変わったことだけを示す
+func Diff(old, new *Config) []string {
+	var changes []string
+
+	oldValue := reflect.ValueOf(old).Elem()
+	newValue := reflect.ValueOf(new).Elem()
+	fields := oldValue.Type()
+
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		before := oldValue.Field(i).Interface()
+		after := newValue.Field(i).Interface()
+		if reflect.DeepEqual(before, after) {
+			continue
+		}
+
+		name := field.Tag.Get("yaml")
+		if name == "" {
+			name = field.Name
+		}
+
+		if field.Tag.Get("secret") == "true" {
+			changes = append(changes, fmt.Sprintf("%s: (変更あり)", name))
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s: %v -> %v", name, before, after))
+	}
+
+	return changes
+}
@@ -0,0 +1,65 @@
+package config
+
+// Feature はAPIキーなどの設定がないと使えない機能を表す
+type Feature string
+
+// 設定に依存する機能の一覧
+const (
+	FeatureNews    Feature = "news"    // ニュース配信（RSS2JSON）
+	FeatureGourmet Feature = "gourmet" // グルメ検索（ホットペッパー）
+	FeatureImage   Feature = "image"   // 画像検索（Google カスタム検索）
+	FeatureVideo   Feature = "video"   // 動画検索（YouTube Data API）
+)
+
+// Features は設定に依存する全ての機能を表示用の順番で並べたもの
+var Features = []Feature{FeatureNews, FeatureGourmet, FeatureImage, FeatureVideo}
+
+// requiredKey は機能に必要な設定項目1つ分（環境変数名と現在の値）
+type requiredKey struct {
+	name  string
+	value string
+}
+
+// MissingKeys は指定された機能を使うために必要で、まだ設定されていない環境変数名を返す
+// 必要な設定がそろっていれば空のスライスを返す
+func (c *Config) MissingKeys(feature Feature) []string {
+	var required []requiredKey
+	switch feature {
+	case FeatureNews:
+		required = []requiredKey{{"RSS2JSON_API_KEY", c.RSS2JSONAPIKey}}
+	case FeatureGourmet:
+		required = []requiredKey{{"RECRUIT_API_KEY", c.RecruitAPIKey}}
+	case FeatureImage:
+		required = []requiredKey{
+			{"CUSTOM_SEARCH_ENGINE_ID", c.CustomSearchEngineID},
+			{"CUSTOM_SEARCH_API_KEY", c.CustomSearchAPIKey},
+		}
+	case FeatureVideo:
+		required = []requiredKey{{"YOUTUBE_DATA_API_KEY", c.YouTubeDataAPIKey}}
+	}
+
+	var missing []string
+	for _, key := range required {
+		if key.value == "" {
+			missing = append(missing, key.name)
+		}
+	}
+	return missing
+}
+
+// Enabled は指定された機能に必要な設定がそろっているかどうかを返す
+// 空のFeatureは設定に依存しない機能として常にtrueを返す
+func (c *Config) Enabled(feature Feature) bool {
+	return len(c.MissingKeys(feature)) == 0
+}
+
+// DisabledFeatures は必要な設定が足りず使えない機能と、足りない環境変数名の対応表を返す
+func (c *Config) DisabledFeatures() map[Feature][]string {
+	disabled := make(map[Feature][]string)
+	for _, feature := range Features {
+		if missing := c.MissingKeys(feature); len(missing) > 0 {
+			disabled[feature] = missing
+		}
+	}
+	return disabled
+}
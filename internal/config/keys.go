@@ -0,0 +1,17 @@
+package config
+
+// 設定のマップに書けるキーの一覧
+// マップのキーを書き間違えると黙って無視されてしまうため、Validateで知らないキーをエラーにする
+var (
+	// QuotaUpstreams は quotas に書ける、利用回数を数える外部APIの名前
+	QuotaUpstreams = []string{"custom_search", "youtube", "hotpepper"}
+
+	// CachedAPIs は cache.ttls に書ける、レスポンスをキャッシュする外部APIの名前
+	CachedAPIs = []string{"weather", "news", "youtube"}
+
+	// Commands は cooldowns に書けるコマンド名（別名ではなく本来の名前）
+	Commands = []string{
+		"weather", "news", "gourmet", "image", "dice", "rank", "eng", "jpn", "video", "vtuber",
+		"home", "quota", "ping", "config", "perm", "help",
+	}
+)
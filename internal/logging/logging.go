@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// ログの出力形式
+const (
+	FormatText = "text" // 「key=value」形式（人が読む用）
+	FormatJSON = "json" // 1行1つのJSON（ログ収集ツール用）
+)
+
+// level は既定のロガーの出力レベル（設定の再読み込みで変更できるよう共有する）
+var level slog.LevelVar
+
+// loggerKey はコンテキストにロガーを持たせる時のキー
+type loggerKey struct{}
+
+// Setup は指定された形式とレベルでwに出力するロガーを、slogとlogパッケージの既定のロガーとして設定する
+// levelは「debug」「info」「warn」「error」のいずれか
+func Setup(w io.Writer, format, levelName string) error {
+	if err := SetLevel(levelName); err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: &level}
+	var handler slog.Handler
+	switch format {
+	case FormatText, "":
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format: %q", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// SetLevel は既定のロガーの出力レベルを変更する
+func SetLevel(levelName string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(levelName)); err != nil {
+		return fmt.Errorf("unknown log level: %q", levelName)
+	}
+	level.Set(l)
+	return nil
+}
+
+// With はコンテキストのロガーに属性を追加したロガーを持つ、新しいコンテキストを返す
+// 以降そのコンテキストを受け取った処理のログには、追加した属性が付く
+func With(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, loggerKey{}, FromContext(ctx).With(args...))
+}
+
+// FromContext はコンテキストが持つロガーを返す（持っていなければ既定のロガー）
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// NewCorrelationID は1つのメッセージの処理に関するログをまとめて探せるよう、ランダムなIDを作る
+func NewCorrelationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
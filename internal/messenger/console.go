@@ -0,0 +1,52 @@
+package messenger
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Console はボットの発言をターミナルに表示するMessenger実装
+// 履歴の管理はFakeに任せ、送信や編集の内容を出力先に書き出す
+type Console struct {
+	*Fake
+	out io.Writer // ボットの発言の出力先
+}
+
+// NewConsole は指定された出力先に書き出すConsoleを作成
+func NewConsole(out io.Writer) *Console {
+	return &Console{
+		Fake: NewFake(),
+		out:  out,
+	}
+}
+
+// Send はメッセージを記録し、出力先に表示
+func (c *Console) Send(channelID, content string) (*discordgo.Message, error) {
+	msg, err := c.Fake.Send(channelID, content)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(c.out, "%s> %s\n", c.Self().Username, content)
+	return msg, nil
+}
+
+// Edit はメッセージを書き換え、書き換え後の内容を出力先に表示
+func (c *Console) Edit(channelID, messageID, content string) (*discordgo.Message, error) {
+	msg, err := c.Fake.Edit(channelID, messageID, content)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(c.out, "%s> (編集) %s\n", c.Self().Username, content)
+	return msg, nil
+}
+
+// React はリアクションを記録し、出力先に表示
+func (c *Console) React(channelID, messageID, emoji string) error {
+	if err := c.Fake.React(channelID, messageID, emoji); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "%s> (リアクション) %s\n", c.Self().Username, emoji)
+	return nil
+}
@@ -0,0 +1,45 @@
+package messenger
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord はdiscordgo.Sessionを使って実際のDiscordとやり取りするMessenger実装
+type Discord struct {
+	session *discordgo.Session // Discord APIとの通信セッション
+}
+
+// NewDiscord はDiscordセッションをMessengerとして扱うためのラッパーを作成
+func NewDiscord(session *discordgo.Session) *Discord {
+	return &Discord{session: session}
+}
+
+// Send は指定されたチャンネルにメッセージを送信
+func (d *Discord) Send(channelID, content string) (*discordgo.Message, error) {
+	return d.session.ChannelMessageSend(channelID, content)
+}
+
+// Edit は送信済みのメッセージの内容を書き換える
+func (d *Discord) Edit(channelID, messageID, content string) (*discordgo.Message, error) {
+	return d.session.ChannelMessageEdit(channelID, messageID, content)
+}
+
+// History は指定されたチャンネルの過去のメッセージを新しい順に取得
+func (d *Discord) History(channelID string, limit int, beforeID string) ([]*discordgo.Message, error) {
+	return d.session.ChannelMessages(channelID, limit, beforeID, "", "")
+}
+
+// React は指定されたメッセージにリアクションを付ける
+func (d *Discord) React(channelID, messageID, emoji string) error {
+	return d.session.MessageReactionAdd(channelID, messageID, emoji)
+}
+
+// Typing は指定されたチャンネルに「入力中…」を表示する
+func (d *Discord) Typing(channelID string) error {
+	return d.session.ChannelTyping(channelID)
+}
+
+// Permissions は指定されたチャンネルでユーザーが持つ権限を返す
+func (d *Discord) Permissions(channelID, userID string) (int64, error) {
+	return d.session.UserChannelPermissions(userID, channelID)
+}
@@ -0,0 +1,184 @@
+package messenger
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Reaction はFakeに記録されたリアクション1件を表す構造体
+type Reaction struct {
+	ChannelID string // リアクションを付けたチャンネルのID
+	MessageID string // リアクションを付けたメッセージのID
+	Emoji     string // 付けた絵文字
+}
+
+// Fake はDiscordに接続せず、送受信したメッセージをメモリ上に記録するMessenger実装
+// コマンドの出力を確認するテストなどで使う
+type Fake struct {
+	mu        sync.Mutex
+	self      *discordgo.User // ボット自身として扱うユーザー
+	nextID    int
+	messages  map[string][]*discordgo.Message // チャンネルIDごとのメッセージ（古い順）
+	sent      []*discordgo.Message            // ボットが送信したメッセージ（送信順）
+	reactions []Reaction                      // ボットが付けたリアクション（付けた順）
+	perms     map[string]int64                // ユーザーIDごとの権限（全チャンネル共通）
+	sendErr   error                           // nilでなければSendが記録せずに返すエラー
+}
+
+// NewFake は空のFakeを作成
+func NewFake() *Fake {
+	return &Fake{
+		self:     &discordgo.User{ID: "kizuna", Username: "kizuna", Bot: true},
+		messages: make(map[string][]*discordgo.Message),
+		perms:    make(map[string]int64),
+	}
+}
+
+// Self はFakeがボット自身として扱うユーザーを返す
+// 送信したメッセージの投稿者やメンション判定に使う
+func (f *Fake) Self() *discordgo.User {
+	return f.self
+}
+
+// AddMessage はユーザーの投稿などをチャンネルの履歴に追加
+// IDが空の場合は自動で採番する
+func (f *Fake) AddMessage(msg *discordgo.Message) *discordgo.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if msg.ID == "" {
+		msg.ID = f.newID()
+	}
+	f.messages[msg.ChannelID] = append(f.messages[msg.ChannelID], msg)
+	return msg
+}
+
+// Send はメッセージを送信したものとして記録
+func (f *Fake) Send(channelID, content string) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.sendErr != nil {
+		return nil, f.sendErr
+	}
+
+	msg := &discordgo.Message{
+		ID:        f.newID(),
+		ChannelID: channelID,
+		Content:   content,
+		Author:    f.self,
+	}
+	f.messages[channelID] = append(f.messages[channelID], msg)
+	f.sent = append(f.sent, msg)
+	return msg, nil
+}
+
+// Edit は記録済みのメッセージの内容を書き換える
+func (f *Fake) Edit(channelID, messageID, content string) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, msg := range f.messages[channelID] {
+		if msg.ID == messageID {
+			msg.Content = content
+			return msg, nil
+		}
+	}
+	return nil, fmt.Errorf("message %s not found in channel %s", messageID, channelID)
+}
+
+// History は記録済みのメッセージを新しい順に返す
+func (f *Fake) History(channelID string, limit int, beforeID string) ([]*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	messages := f.messages[channelID]
+	end := len(messages)
+	if beforeID != "" {
+		for i, msg := range messages {
+			if msg.ID == beforeID {
+				end = i
+				break
+			}
+		}
+	}
+
+	var result []*discordgo.Message
+	for i := end - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, messages[i])
+	}
+	return result, nil
+}
+
+// React はリアクションを付けたものとして記録
+func (f *Fake) React(channelID, messageID, emoji string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.reactions = append(f.reactions, Reaction{ChannelID: channelID, MessageID: messageID, Emoji: emoji})
+	return nil
+}
+
+// Permissions はSetPermissionsで設定された権限を返す（未設定のユーザーは権限なし）
+func (f *Fake) Permissions(channelID, userID string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.perms[userID], nil
+}
+
+// Typing は何もしない（入力中の表示は記録する必要がないため）
+func (f *Fake) Typing(channelID string) error {
+	return nil
+}
+
+// SetPermissions はユーザーが持つ権限を設定
+func (f *Fake) SetPermissions(userID string, perms int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.perms[userID] = perms
+}
+
+// SetSendError はSendが返すエラーを設定する（送信に失敗した時の動作を確かめるため。nilで元に戻す）
+func (f *Fake) SetSendError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sendErr = err
+}
+
+// Sent はボットが送信したメッセージを送信順に返す
+func (f *Fake) Sent() []*discordgo.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]*discordgo.Message(nil), f.sent...)
+}
+
+// Reactions はボットが付けたリアクションを付けた順に返す
+func (f *Fake) Reactions() []Reaction {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Reaction(nil), f.reactions...)
+}
+
+// Reset は記録した内容を全て消去
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.messages = make(map[string][]*discordgo.Message)
+	f.sent = nil
+	f.reactions = nil
+}
+
+// newID は連番のメッセージIDを発行する（呼び出し側でロックを取得しておくこと）
+func (f *Fake) newID() string {
+	f.nextID++
+	return strconv.Itoa(f.nextID)
+}
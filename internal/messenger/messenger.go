@@ -0,0 +1,23 @@
+package messenger
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// Messenger はボットがチャンネルとやり取りするための最小限の操作をまとめたインターフェース
+// 本番ではDiscord、テストやローカル実行ではメモリ上の実装に差し替えられる
+type Messenger interface {
+	// Send は指定されたチャンネルにメッセージを送信
+	Send(channelID, content string) (*discordgo.Message, error)
+	// Edit は送信済みのメッセージの内容を書き換える
+	Edit(channelID, messageID, content string) (*discordgo.Message, error)
+	// History は指定されたチャンネルの過去のメッセージを新しい順に最大limit件取得
+	// beforeIDが空でなければ、そのメッセージより古いものだけを返す
+	History(channelID string, limit int, beforeID string) ([]*discordgo.Message, error)
+	// React は指定されたメッセージにリアクション（絵文字）を付ける
+	React(channelID, messageID, emoji string) error
+	// Permissions は指定されたチャンネルでユーザーが持つ権限（discordgo.Permission*のビット和）を返す
+	Permissions(channelID, userID string) (int64, error)
+	// Typing は指定されたチャンネルに「入力中…」を表示する（数秒で自動的に消える）
+	Typing(channelID string) error
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace は全てのメトリクス名に付ける接頭辞
+const namespace = "kizuna"
+
+// registry はボットのメトリクスを登録するレジストリ
+// 他のライブラリが既定のレジストリに登録したものが混ざらないよう、専用のものを使う
+var registry = prometheus.NewRegistry()
+
+var (
+	// Commands はコマンドの実行回数（resultは executed, disabled, denied, unavailable, cooldown, panic のいずれか）
+	Commands = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "commands_total",
+		Help:      "Number of commands handled, by command name and result.",
+	}, []string{"command", "result"})
+
+	// MunouIntents はメンションへの応答で、どの話題として応答したかの回数
+	MunouIntents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "munou_intents_total",
+		Help:      "Number of mention replies, by detected intent.",
+	}, []string{"intent"})
+
+	// UpstreamDuration は外部APIへのリクエスト1回にかかった時間（再試行は別々に数える）
+	UpstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Latency of requests to upstream APIs.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"api"})
+
+	// UpstreamErrors は外部APIの呼び出しの失敗回数
+	// reasonは network, http_4xx, http_5xx, circuit_open のいずれか
+	UpstreamErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_errors_total",
+		Help:      "Number of failed requests to upstream APIs, by API and reason.",
+	}, []string{"api", "reason"})
+
+	// GatewayConnected はDiscordのゲートウェイに接続しているかどうか（1なら接続中）
+	GatewayConnected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "gateway_connected",
+		Help:      "Whether the Discord gateway connection is up (1) or down (0).",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		Commands,
+		MunouIntents,
+		UpstreamDuration,
+		UpstreamErrors,
+		GatewayConnected,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler はPrometheusの形式でメトリクスを返すHTTPハンドラーを返す
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
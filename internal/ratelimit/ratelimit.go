@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// cleanupInterval は使われなくなったバケットを掃除する間隔
+const cleanupInterval = 10 * time.Minute
+
+// Rule はトークンバケットの設定
+// Burst回まで連続で実行でき、その後はIntervalごとに1回分ずつ回復する
+type Rule struct {
+	Burst    int           `yaml:"burst"`    // 連続で実行できる回数（バケットの容量）
+	Interval time.Duration `yaml:"interval"` // 1回分のトークンが回復するまでの時間
+}
+
+// Enabled はルールが有効（制限あり）かどうかを返す
+func (r Rule) Enabled() bool {
+	return r.Burst > 0 && r.Interval > 0
+}
+
+// bucket は1つのキーに対応するトークンバケットの状態
+type bucket struct {
+	tokens float64       // 残りのトークン数
+	last   time.Time     // 最後にトークン数を計算した時刻
+	full   time.Duration // 空の状態から満タンまで回復するのにかかる時間
+}
+
+// Limit はトークンを消費するバケットのキーと、そのバケットに適用するルールの組
+type Limit struct {
+	Key  string
+	Rule Rule
+}
+
+// Limiter はキーごとにトークンバケットを管理するレートリミッター
+type Limiter struct {
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+	now         func() time.Time // 現在時刻（テストで差し替える）
+}
+
+// New は新しいLimiterを作成
+func New() *Limiter {
+	return &Limiter{
+		buckets:     make(map[string]*bucket),
+		lastCleanup: time.Now(),
+		now:         time.Now,
+	}
+}
+
+// Allow はキーに対応するバケットからトークンを1つ消費できるかを確認する
+// 消費できた場合はtrueを、できなかった場合はfalseと次に実行できるまでの待ち時間を返す
+// ルールが無効な場合は制限なしとして常にtrueを返す
+func (l *Limiter) Allow(key string, rule Rule) (bool, time.Duration) {
+	return l.AllowAll(Limit{Key: key, Rule: rule})
+}
+
+// AllowAll は全てのバケットからトークンを1つずつ消費できるかを確認する
+// 1つでも足りないバケットがあればどのバケットからも消費せず、falseと最も長い待ち時間を返す
+// （ユーザーごとの制限は満たしていてもチャンネルごとの制限で断った時に、ユーザーの分だけ減らないようにするため）
+// ルールが無効なものは制限なしとして扱う
+func (l *Limiter) AllowAll(limits ...Limit) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+
+	var wait time.Duration
+	buckets := make([]*bucket, 0, len(limits))
+	for _, limit := range limits {
+		if !limit.Rule.Enabled() {
+			continue
+		}
+		b := l.refill(limit.Key, limit.Rule, now)
+		buckets = append(buckets, b)
+		if b.tokens < 1 {
+			wait = max(wait, time.Duration((1-b.tokens)*float64(limit.Rule.Interval)))
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+
+	for _, b := range buckets {
+		b.tokens--
+	}
+	return true, 0
+}
+
+// refill はキーに対応するバケットのトークンを、前回からの経過時間に応じて回復させて返す（呼び出し側でロックを取得しておくこと）
+func (l *Limiter) refill(key string, rule Rule, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.full = time.Duration(rule.Burst) * rule.Interval
+
+	// 容量を超えては回復しない
+	elapsed := now.Sub(b.last)
+	b.tokens = math.Min(float64(rule.Burst), b.tokens+elapsed.Seconds()/rule.Interval.Seconds())
+	b.last = now
+	return b
+}
+
+// cleanup は満タンまで回復していて、もう覚えておく必要のないバケットを定期的に削除する
+// 長時間動かし続けてもメモリを使い続けないようにするため（呼び出し側でロックを取得しておくこと）
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= b.full {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock はテストで進める時計
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter() (*Limiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := New()
+	l.now = clock.now
+	l.lastCleanup = clock.t
+	return l, clock
+}
+
+// step はテストで1回ずつ行う操作（advanceだけ時計を進めてからAllowAllを呼ぶ）
+type step struct {
+	advance  time.Duration
+	limits   []Limit
+	wantOK   bool
+	wantWait time.Duration
+}
+
+var (
+	userRule    = Rule{Burst: 2, Interval: 10 * time.Second}
+	channelRule = Rule{Burst: 3, Interval: time.Minute}
+	user        = Limit{Key: "user", Rule: userRule}
+	otherUser   = Limit{Key: "other", Rule: userRule}
+	channel     = Limit{Key: "channel", Rule: channelRule}
+	// slowUser はチャンネルより回復が遅いユーザーの制限
+	slowUser = Limit{Key: "slow", Rule: Rule{Burst: 2, Interval: time.Hour}}
+)
+
+func TestAllowAll(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "burst回まで続けて使える",
+			steps: []step{
+				{limits: []Limit{user}, wantOK: true},
+				{limits: []Limit{user}, wantOK: true},
+				{limits: []Limit{user}, wantOK: false, wantWait: 10 * time.Second},
+			},
+		},
+		{
+			name: "intervalごとに1回分回復する",
+			steps: []step{
+				{limits: []Limit{user}, wantOK: true},
+				{limits: []Limit{user}, wantOK: true},
+				{advance: 4 * time.Second, limits: []Limit{user}, wantOK: false, wantWait: 6 * time.Second},
+				{advance: 6 * time.Second, limits: []Limit{user}, wantOK: true},
+				{limits: []Limit{user}, wantOK: false, wantWait: 10 * time.Second},
+			},
+		},
+		{
+			name: "容量を超えては回復しない",
+			steps: []step{
+				{limits: []Limit{user}, wantOK: true},
+				{advance: time.Hour, limits: []Limit{user}, wantOK: true},
+				{limits: []Limit{user}, wantOK: true},
+				{limits: []Limit{user}, wantOK: false, wantWait: 10 * time.Second},
+			},
+		},
+		{
+			name: "無効なルールは制限しない",
+			steps: []step{
+				{limits: []Limit{{Key: "none", Rule: Rule{}}}, wantOK: true},
+				{limits: []Limit{{Key: "none", Rule: Rule{Burst: 1}}}, wantOK: true},
+				{limits: []Limit{{Key: "none", Rule: Rule{}}}, wantOK: true},
+			},
+		},
+		{
+			name: "チャンネルの制限で断った時はユーザーの分を減らさない",
+			steps: []step{
+				{limits: []Limit{otherUser, channel}, wantOK: true},
+				{limits: []Limit{otherUser, channel}, wantOK: true},
+				{limits: []Limit{slowUser, channel}, wantOK: true},
+				// チャンネルのトークンが尽きた
+				{limits: []Limit{slowUser, channel}, wantOK: false, wantWait: time.Minute},
+				// チャンネルが1回分回復した時、ユーザーにはまだ1回分残っている
+				{advance: time.Minute, limits: []Limit{slowUser, channel}, wantOK: true},
+				{limits: []Limit{slowUser}, wantOK: false, wantWait: time.Hour - time.Minute},
+			},
+		},
+		{
+			name: "両方足りない時は長い方の待ち時間を返す",
+			steps: []step{
+				{limits: []Limit{user, channel}, wantOK: true},
+				{limits: []Limit{user, channel}, wantOK: true},
+				{limits: []Limit{otherUser, channel}, wantOK: true},
+				{limits: []Limit{user, channel}, wantOK: false, wantWait: time.Minute},
+				// ユーザーは回復したが、チャンネルはまだ
+				{advance: 20 * time.Second, limits: []Limit{user, channel}, wantOK: false, wantWait: 40 * time.Second},
+				{limits: []Limit{user}, wantOK: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := newTestLimiter()
+			for i, s := range tt.steps {
+				clock.advance(s.advance)
+				ok, wait := l.AllowAll(s.limits...)
+				if ok != s.wantOK || (wait-s.wantWait).Abs() > time.Millisecond {
+					t.Fatalf("step %d: AllowAll = (%v, %v), want (%v, %v)", i, ok, wait, s.wantOK, s.wantWait)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanupForgetsRefilledBuckets(t *testing.T) {
+	l, clock := newTestLimiter()
+	l.Allow("user", userRule)
+
+	clock.advance(cleanupInterval)
+	l.Allow("channel", channelRule)
+	if _, ok := l.buckets["user"]; ok {
+		t.Error("refilled bucket was not cleaned up")
+	}
+	if _, ok := l.buckets["channel"]; !ok {
+		t.Error("bucket in use was cleaned up")
+	}
+}
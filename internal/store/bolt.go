@@ -0,0 +1,112 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltStore はbbolt（単一ファイルの組み込みKVS）を使ったStore実装
+// 外部のデータベースサーバーを必要としないため、小さなVPSでもそのまま動作する
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// Open は指定されたパスのデータベースファイルを開き（なければ作成し）、マイグレーションを適用
+func Open(path string) (*BoltStore, error) {
+	// 別プロセスがファイルを使用中の場合にいつまでも待たないようにタイムアウトを設定
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Get はキーに対応する値をvに読み込み、値が存在したかどうかを返す
+func (s *BoltStore) Get(bucket Bucket, key string, v any) (bool, error) {
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("%w: %s", ErrBucketNotFound, bucket)
+		}
+
+		data := b.Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, v)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get %s/%s: %w", bucket, key, err)
+	}
+	return found, nil
+}
+
+// Put はvをJSONにしてキーに対応する値として保存
+func (s *BoltStore) Put(bucket Bucket, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s/%s: %w", bucket, key, err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("%w: %s", ErrBucketNotFound, bucket)
+		}
+		return b.Put([]byte(key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// Delete はキーに対応する値を削除
+func (s *BoltStore) Delete(bucket Bucket, key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("%w: %s", ErrBucketNotFound, bucket)
+		}
+		return b.Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// ForEach はBucket内の全ての値をキーの昇順に読み出す
+func (s *BoltStore) ForEach(bucket Bucket, fn func(key string, decode func(v any) error) error) error {
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("%w: %s", ErrBucketNotFound, bucket)
+		}
+		return b.ForEach(func(k, data []byte) error {
+			return fn(string(k), func(v any) error {
+				return json.Unmarshal(data, v)
+			})
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to iterate %s: %w", bucket, err)
+	}
+	return nil
+}
+
+// Close はデータベースファイルを閉じる
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
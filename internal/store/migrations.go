@@ -0,0 +1,112 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// metaBucket はスキーマのバージョンなど、Store自体の管理情報を保存するBucket
+const metaBucket = "meta"
+
+// schemaVersionKey は適用済みのマイグレーションのバージョンを保存するキー
+const schemaVersionKey = "schema_version"
+
+// migration はスキーマを1段階進めるための変更を表す構造体
+type migration struct {
+	version     int                  // 適用後のスキーマバージョン（1から連番）
+	description string               // ログに表示する変更内容
+	up          func(*bolt.Tx) error // 変更処理
+}
+
+// migrations は適用するマイグレーションの一覧（バージョン順）
+// 新しいBucketなどが必要になった場合は末尾に追加する。適用済みのものは変更しないこと
+var migrations = []migration{
+	{
+		version:     1,
+		description: "initial schema",
+		up: func(tx *bolt.Tx) error {
+			return nil
+		},
+	},
+	{
+		version:     2,
+		description: "add guild settings",
+		up:          createBuckets(BucketGuildSettings),
+	},
+	{
+		version:     3,
+		description: "add quota usage",
+		up:          createBuckets(BucketQuotaUsage),
+	},
+	{
+		version:     4,
+		description: "add response cache",
+		up:          createBuckets(BucketResponseCache),
+	},
+	{
+		version:     5,
+		description: "add user settings",
+		up:          createBuckets(BucketUserSettings),
+	},
+	{
+		version:     6,
+		description: "add weather schedules",
+		up:          createBuckets(BucketWeatherSchedules),
+	},
+	{
+		version:     7,
+		description: "add rain alerts",
+		up:          createBuckets(BucketRainAlerts),
+	},
+}
+
+// createBuckets は指定されたBucketをまとめて作成するマイグレーション処理を返す
+func createBuckets(buckets ...Bucket) func(*bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		for _, bucket := range buckets {
+			if _, err := tx.CreateBucketIfNotExists([]byte(bucket)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// migrate は未適用のマイグレーションを順番に適用する
+// 全ての変更は1つのトランザクションで行われ、途中で失敗した場合は何も適用されない
+func migrate(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists([]byte(metaBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create meta bucket: %w", err)
+		}
+
+		current := 0
+		if data := meta.Get([]byte(schemaVersionKey)); data != nil {
+			current = int(binary.BigEndian.Uint64(data))
+		}
+
+		if latest := migrations[len(migrations)-1].version; current > latest {
+			return fmt.Errorf("database schema version %d is newer than supported version %d", current, latest)
+		}
+
+		for _, m := range migrations {
+			if m.version <= current {
+				continue
+			}
+
+			if err := m.up(tx); err != nil {
+				return fmt.Errorf("failed to apply migration %d (%s): %w", m.version, m.description, err)
+			}
+			slog.Info("データベースのマイグレーションを適用しました", "version", m.version, "description", m.description)
+			current = m.version
+		}
+
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, uint64(current))
+		return meta.Put([]byte(schemaVersionKey), data)
+	})
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// allBuckets はマイグレーションで作成されるべき全てのBucket
+var allBuckets = []Bucket{
+	BucketGuildSettings,
+	BucketQuotaUsage,
+	BucketResponseCache,
+	BucketUserSettings,
+	BucketWeatherSchedules,
+	BucketRainAlerts,
+}
+
+// openBolt は一時ディレクトリにマイグレーション前の空のデータベースを作成する
+func openBolt(t *testing.T) (*bolt.DB, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kizuna.db")
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("bolt.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, path
+}
+
+// setSchema はversionまでのマイグレーションだけを適用した状態（古いバージョンのボットが作ったデータベース）にする
+// migrationsにないversionを指定した場合は、スキーマのバージョンだけを書き込む
+func setSchema(t *testing.T, db *bolt.DB, version int) {
+	t.Helper()
+	err := db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists([]byte(metaBucket))
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if m.version > version {
+				break
+			}
+			if err := m.up(tx); err != nil {
+				return err
+			}
+		}
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, uint64(version))
+		return meta.Put([]byte(schemaVersionKey), data)
+	})
+	if err != nil {
+		t.Fatalf("setSchema(%d): %v", version, err)
+	}
+}
+
+// schemaVersion はデータベースに記録されたスキーマのバージョンを返す
+func schemaVersion(t *testing.T, db *bolt.DB) int {
+	t.Helper()
+	version := 0
+	db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket([]byte(metaBucket)); meta != nil {
+			if data := meta.Get([]byte(schemaVersionKey)); data != nil {
+				version = int(binary.BigEndian.Uint64(data))
+			}
+		}
+		return nil
+	})
+	return version
+}
+
+func TestMigrate(t *testing.T) {
+	latest := migrations[len(migrations)-1].version
+
+	// 空のデータベース（0）と、途中までのマイグレーションが適用されたデータベースから最新にする
+	for from := 0; from <= latest; from++ {
+		t.Run(fmt.Sprintf("v%dから", from), func(t *testing.T) {
+			db, _ := openBolt(t)
+			if from > 0 {
+				setSchema(t, db, from)
+			}
+			// 既に保存されているデータはマイグレーションで消えない
+			err := db.Update(func(tx *bolt.Tx) error {
+				b, err := tx.CreateBucketIfNotExists([]byte("existing"))
+				if err != nil {
+					return err
+				}
+				return b.Put([]byte("key"), []byte("value"))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := migrate(db); err != nil {
+				t.Fatalf("migrate: %v", err)
+			}
+			if got := schemaVersion(t, db); got != latest {
+				t.Errorf("schema version = %d, want %d", got, latest)
+			}
+			db.View(func(tx *bolt.Tx) error {
+				for _, bucket := range allBuckets {
+					if tx.Bucket([]byte(bucket)) == nil {
+						t.Errorf("bucket %s was not created", bucket)
+					}
+				}
+				if b := tx.Bucket([]byte("existing")); b == nil || string(b.Get([]byte("key"))) != "value" {
+					t.Error("existing data was lost")
+				}
+				return nil
+			})
+
+			// 適用済みなら何もしない
+			if err := migrate(db); err != nil {
+				t.Fatalf("second migrate: %v", err)
+			}
+			if got := schemaVersion(t, db); got != latest {
+				t.Errorf("schema version after second migrate = %d, want %d", got, latest)
+			}
+		})
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	latest := migrations[len(migrations)-1].version
+	db, path := openBolt(t)
+	setSchema(t, db, latest+1)
+
+	err := migrate(db)
+	if err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Fatalf("migrate = %v, want an error about a newer schema", err)
+	}
+	if got := schemaVersion(t, db); got != latest+1 {
+		t.Errorf("schema version = %d, want it left at %d", got, latest+1)
+	}
+
+	// Openも失敗し、ファイルを開いたままにしない
+	db.Close()
+	if _, err := Open(path); err == nil {
+		t.Fatal("Open succeeded on a newer schema")
+	}
+	reopened, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("database was left locked: %v", err)
+	}
+	reopened.Close()
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"errors"
+)
+
+// Bucket はStoreの中でデータを種類ごとに分けて保存するための入れ物の名前
+// 使用するBucketは migrations.go のマイグレーションで作成しておく必要がある
+type Bucket string
+
+// ボットが使用するBucketの一覧
+const (
+	BucketGuildSettings    Bucket = "guild_settings"    // ギルドごとの設定（キーはギルドID）
+	BucketQuotaUsage       Bucket = "quota_usage"       // 外部APIの利用回数（キーはAPIの名前）
+	BucketResponseCache    Bucket = "response_cache"    // 外部APIのレスポンスのキャッシュ（キーはURLのハッシュ）
+	BucketUserSettings     Bucket = "user_settings"     // ユーザーごとの設定（キーはユーザーID）
+	BucketWeatherSchedules Bucket = "weather_schedules" // 天気予報の定期配信の設定（キーはチャンネルID）
+	BucketRainAlerts       Bucket = "rain_alerts"       // 雨のお知らせの登録と、最後に確かめた天気予報（キーはチャンネルID）
+)
+
+// ErrBucketNotFound はマイグレーションで作成されていないBucketを使おうとした時のエラー
+var ErrBucketNotFound = errors.New("bucket not found")
+
+// Store はギルドごとの設定やユーザーの情報など、ボットの状態を永続化するためのリポジトリ
+// 値はJSONとして保存される
+type Store interface {
+	// Get はキーに対応する値をvに読み込み、値が存在したかどうかを返す
+	Get(bucket Bucket, key string, v any) (bool, error)
+	// Put はvをキーに対応する値として保存（既存の値は上書き）
+	Put(bucket Bucket, key string, v any) error
+	// Delete はキーに対応する値を削除（存在しない場合は何もしない）
+	Delete(bucket Bucket, key string) error
+	// ForEach はBucket内の全ての値をキーの昇順に読み出す
+	// decodeを呼ぶと、その値を任意の型に読み込める
+	ForEach(bucket Bucket, fn func(key string, decode func(v any) error) error) error
+	// Close はStoreを閉じる
+	Close() error
+}
@@ -0,0 +1,15 @@
+// Package textutil はメッセージやログに使う文字列を扱う小さな関数をまとめる
+package textutil
+
+// Truncate はsが長ければ、末尾の「…」を含めてmaxRunes文字に収まるように切り詰める
+// バイト数ではなく文字数で数えるため、日本語の途中で切れることはない
+func Truncate(s string, maxRunes int) string {
+	runes := []rune(s)
+	if len(runes) <= maxRunes {
+		return s
+	}
+	if maxRunes < 1 {
+		return ""
+	}
+	return string(runes[:maxRunes-1]) + "…"
+}
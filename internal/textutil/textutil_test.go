@@ -0,0 +1,28 @@
+package textutil
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		maxRunes int
+		want     string
+	}{
+		{name: "短ければそのまま", s: "晴れ", maxRunes: 5, want: "晴れ"},
+		{name: "ちょうどの長さはそのまま", s: "晴れのち曇り", maxRunes: 6, want: "晴れのち曇り"},
+		{name: "「…」を含めて収める", s: "晴れのち曇り", maxRunes: 5, want: "晴れのち…"},
+		{name: "ASCII", s: "hello world", maxRunes: 6, want: "hello…"},
+		{name: "1文字なら「…」だけ", s: "晴れ", maxRunes: 1, want: "…"},
+		{name: "0文字", s: "晴れ", maxRunes: 0, want: ""},
+		{name: "空文字列", s: "", maxRunes: 3, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Truncate(tt.s, tt.maxRunes); got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.maxRunes, got, tt.want)
+			}
+		})
+	}
+}
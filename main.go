@@ -1,40 +1,117 @@
 package main
 
 import (
-	"log"
+	"flag"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/joho/godotenv"
 	"kizuna_bot_go/internal/bot"
+	"kizuna_bot_go/internal/config"
+	"kizuna_bot_go/internal/logging"
 )
 
 func main() {
+	// コマンドライン引数を解析
+	consoleMode := flag.Bool("console", false, "Discordに接続せず、ターミナルからボットと会話する")
+	configPath := flag.String("config", "", "設定ファイル（YAML）のパス（省略時は "+config.DefaultConfigFile+" があれば読み込む）")
+	flag.Parse()
+
 	// .envファイルから環境変数を読み込み（APIキーなどの設定情報）
-	if err := godotenv.Load(); err != nil {
-		log.Println(".envファイルが見つからないため、システム環境変数を使用します")
+	// 既に設定されている環境変数は.envより優先するため、読み込む前に覚えておく（SIGHUPでの再読み込みでも上書きしない）
+	processEnv := environKeys()
+	envErr := godotenv.Load()
+
+	// 設定ファイルと環境変数から設定を読み込み、内容を検証
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fatal("設定の読み込みに失敗しました", err)
+	}
+
+	// 設定に従ってログの形式とレベルを決める（以降のログは全てこの形式で出力される）
+	if err := logging.Setup(os.Stderr, cfg.Log.Format, cfg.Log.Level); err != nil {
+		fatal("ログの設定に失敗しました", err)
+	}
+	if envErr != nil {
+		slog.Info(".envファイルが見つからないため、システム環境変数を使用します")
+	}
+
+	// コンソールモードでは標準入力の内容をメッセージとして処理し、返答を標準出力に表示
+	if *consoleMode {
+		if err := bot.RunConsole(cfg, os.Stdin, os.Stdout); err != nil {
+			fatal("コンソールモードの実行に失敗しました", err)
+		}
+		return
 	}
 
 	// Kizuna Botのインスタンスを作成
-	kizunaBot, err := bot.NewKizunaBot()
+	kizunaBot, err := bot.NewKizunaBot(cfg)
 	if err != nil {
-		log.Fatalf("ボットの作成に失敗しました: %v", err)
+		fatal("ボットの作成に失敗しました", err)
 	}
 
 	// Discordサーバーへの接続を開始
 	if err := kizunaBot.Start(); err != nil {
-		log.Fatalf("ボットの起動に失敗しました: %v", err)
+		fatal("ボットの起動に失敗しました", err)
 	}
 
-	log.Println("ボットが正常に起動しました。終了するにはCTRL-Cを押してください。")
+	slog.Info("ボットが正常に起動しました。終了するにはCTRL-Cを押してください。")
 
 	// システムからの終了シグナル（CTRL-Cなど）を待機
+	// SIGHUPを受け取った場合は終了せずに設定を読み込み直す
 	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
-	<-sc
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, os.Interrupt)
+	for sig := range sc {
+		if sig != syscall.SIGHUP {
+			break
+		}
+
+		slog.Info("SIGHUPを受け取ったため、設定を読み込み直します")
+		// 起動時と同じく、プロセスの環境変数として設定されていた項目以外を.envの内容で更新
+		// （.envから削除された項目は元に戻らない点に注意）
+		if err := reloadEnv(processEnv); err != nil {
+			slog.Info(".envファイルが見つからないため、システム環境変数を使用します")
+		}
+		if err := kizunaBot.Reload(*configPath); err != nil {
+			slog.Error("設定の再読み込みに失敗したため、これまでの設定を使い続けます", "error", err)
+		}
+	}
 
 	// Discordとの接続を安全に切断
 	kizunaBot.Close()
-	log.Println("ボットを正常に終了しました。")
+	slog.Info("ボットを正常に終了しました。")
+}
+
+// fatal はエラーをログに出力してプログラムを終了する
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// environKeys は現在設定されている環境変数の名前の集合を返す
+func environKeys() map[string]bool {
+	keys := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		keys[key] = true
+	}
+	return keys
+}
+
+// reloadEnv は.envファイルを読み込み直し、processEnvに含まれない（.envから設定された）環境変数だけを更新する
+// 起動時のgodotenv.Loadと同じく、プロセスの環境変数が.envより優先される
+func reloadEnv(processEnv map[string]bool) error {
+	values, err := godotenv.Read()
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		if !processEnv[key] {
+			os.Setenv(key, value)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadEnvKeepsProcessEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("KIZUNA_TEST_FROM_PROCESS", "process")
+	t.Setenv("KIZUNA_TEST_FROM_DOTENV", "")
+	os.Unsetenv("KIZUNA_TEST_FROM_DOTENV")
+
+	processEnv := environKeys()
+
+	dotenv := "KIZUNA_TEST_FROM_PROCESS=dotenv\nKIZUNA_TEST_FROM_DOTENV=before\n"
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(dotenv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadEnv(processEnv); err != nil {
+		t.Fatalf("reloadEnv: %v", err)
+	}
+
+	// .envを書き換えてから再読み込みすると、.envから設定した項目だけが変わる
+	dotenv = "KIZUNA_TEST_FROM_PROCESS=changed\nKIZUNA_TEST_FROM_DOTENV=after\n"
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(dotenv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadEnv(processEnv); err != nil {
+		t.Fatalf("reloadEnv: %v", err)
+	}
+
+	if got := os.Getenv("KIZUNA_TEST_FROM_PROCESS"); got != "process" {
+		t.Errorf("KIZUNA_TEST_FROM_PROCESS = %q, want the process value to win", got)
+	}
+	if got := os.Getenv("KIZUNA_TEST_FROM_DOTENV"); got != "after" {
+		t.Errorf("KIZUNA_TEST_FROM_DOTENV = %q, want the reloaded .env value", got)
+	}
+}